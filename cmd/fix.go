@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/fixer"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/MongoCaleb/checker/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRun   bool
+	ownLinks bool
+)
+
+// fixCmd rewrites links in the project's source files
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Rewrites permanently redirected links, upgradable http:// links and configured rewrites in place.",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := runCtx
+		basepath, projectSnooty := loadProject()
+		files := collectors.GatherFiles(basepath)
+
+		allHTTPLinks := collectors.GatherHTTPLinks(files)
+		allConstants := collectors.GatherConstants(files)
+
+		var mu sync.Mutex
+		fixes := make([]fixer.Fix, 0)
+		addFix := func(fix fixer.Fix) {
+			mu.Lock()
+			defer mu.Unlock()
+			fixes = append(fixes, fix)
+		}
+
+		site := CheckerConfig.SiteURL(projectSnooty)
+		pages := collectors.GatherPages(files)
+		localRefs := collectors.GatherLocalRefs(files)
+
+		workStack := make([]job, 0)
+		for link, filename := range allHTTPLinks {
+			if ownLinks {
+				if role, target, ok := ownSiteRole(string(link), site, pages, localRefs); ok {
+					addFix(fixer.RoleFix(string(link), role, target, "links to this docset"))
+					continue
+				}
+			}
+			if isBlocked(string(link)) {
+				continue
+			}
+			workStack = append(workStack, func(link string) job {
+				return job{url: link, filename: filename, run: func(ctx context.Context) bool {
+					newURL, reason, ok := fixFor(ctx, link)
+					if ctx.Err() != nil {
+						return false
+					}
+					if ok {
+						addFix(fixer.Fix{Old: link, New: newURL, Reason: reason})
+					}
+					return true
+				}}
+			}(string(link)))
+		}
+		for con, filename := range allConstants {
+			prefix, ok := projectSnooty.Constants[con.Name]
+			if !ok {
+				continue
+			}
+			testCon := rst.RstConstant{Name: con.Name, Target: prefix + con.Target}
+			if isBlocked(testCon.Target) || !testCon.IsHTTPLink() {
+				continue
+			}
+			workStack = append(workStack, func(con rst.RstConstant, prefix string) job {
+				expanded := prefix + con.Target
+				return job{url: expanded, filename: filename, run: func(ctx context.Context) bool {
+					newURL, reason, ok := fixFor(ctx, expanded)
+					if ctx.Err() != nil {
+						return false
+					}
+					if !ok {
+						return true
+					}
+					if fix, ok := fixer.ConstantFix(con.Name, prefix, con.Target, newURL, reason); ok {
+						addFix(fix)
+					} else {
+						log.Warnf("%s should become %s (%s), but that changes the value of {+%s+}; update snooty.toml instead", expanded, newURL, reason, con.Name)
+					}
+					return true
+				}}
+			}(con, prefix))
+		}
+
+		workStack, hosts := preflight(ctx, workStack)
+		for _, h := range hosts {
+			if h.err != nil {
+				_, reason := utils.ClassifyError(h.err)
+				log.Warnf("%s is unreachable (%s), %d links on it were left unchanged.", h.origin, reason, len(h.jobs))
+			}
+		}
+		if skipped := runJobs(ctx, workStack); len(skipped) > 0 {
+			log.Warnf("%d of %d links were not checked, applying the fixes found so far.", len(skipped), len(workStack))
+		}
+
+		changed := 0
+		for _, file := range files {
+			dat, err := collectors.FSUtil.ReadFile(file)
+			checkErr(err)
+			fixed := fixer.Apply(dat, fixes)
+			if bytes.Equal(dat, fixed) {
+				continue
+			}
+			changed++
+			name := strings.TrimPrefix(strings.TrimPrefix(file, basepath), "/")
+			if dryRun {
+				diff, err := fixer.UnifiedDiff(name, dat, fixed)
+				if err != nil {
+					log.Errorf("can't show the changes to %s: %v", name, err)
+					continue
+				}
+				fmt.Fprint(cmd.OutOrStdout(), diff)
+				continue
+			}
+			info, err := collectors.FS.Stat(file)
+			checkErr(err)
+			checkErr(collectors.FSUtil.WriteFile(file, fixed, info.Mode()))
+		}
+
+		if loglevel > 0 {
+			for _, fix := range fixes {
+				log.Infof("%s -> %s (%s)", fix.Old, fix.New, fix.Reason)
+			}
+			if dryRun {
+				log.Infof("%d files would be changed.", changed)
+			} else {
+				log.Infof("%d files changed.", changed)
+			}
+		}
+	},
+}
+
+func init() {
+	fixCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print a unified diff instead of rewriting files")
+	fixCmd.Flags().BoolVar(&ownLinks, "own-links", false, "replace absolute links to pages of this docset with :doc: and :ref: roles")
+	rootCmd.AddCommand(fixCmd)
+}
+
+// fixFor works out what a link should be replaced with: configured rewrites are applied first, then
+// permanent redirects are followed, then http:// is upgraded if the https:// version serves the same page.
+func fixFor(ctx context.Context, link string) (string, string, bool) {
+	current, reasons := CheckerConfig.Rewrite(link)
+
+	resp, ok := linkChecker.Check(ctx, current)
+	if target := resp.PermanentTarget(); ok && target != "" {
+		current = keepFragment(current, target)
+		reasons = append(reasons, "permanent redirect")
+	}
+
+	if strings.HasPrefix(current, "http://") && utils.UpgradesToHTTPS(ctx, linkChecker, current) {
+		current = "https://" + strings.TrimPrefix(current, "http://")
+		reasons = append(reasons, "https available")
+	}
+
+	if current == link {
+		return "", "", false
+	}
+	return current, strings.Join(reasons, ", "), true
+}
+
+// keepFragment carries the fragment of the original link over to its redirect target, as browsers do.
+func keepFragment(original, target string) string {
+	o, err := url.Parse(original)
+	if err != nil || o.Fragment == "" {
+		return target
+	}
+	t, err := url.Parse(target)
+	if err != nil || t.Fragment != "" {
+		return target
+	}
+	return target + "#" + o.EscapedFragment()
+}
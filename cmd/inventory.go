@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/MongoCaleb/checker/internal/docset"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/MongoCaleb/checker/internal/utils"
+)
+
+// fetchedPage is the body of a page fetched once to look for the anchors of inventory urls on it.
+type fetchedPage struct {
+	once sync.Once
+	body []byte
+	ok   bool
+}
+
+// staleReason returns why the inventory url uri is stale, given the result of checking it: it is broken,
+// or the page it is on lacks its anchor. pages holds a fetchedPage for each page, by its normalized url, so that
+// each is fetched once. Anchors aren't looked for on pages that can't be fetched.
+func staleReason(ctx context.Context, pages *sync.Map, uri string, resp utils.HttpResponse, ok bool) (string, bool) {
+	if !ok {
+		if resp.Kind != "" {
+			return resp.Message, true
+		}
+		return fmt.Sprintf("it answers %d", resp.Code), true
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Fragment == "" {
+		return "", false
+	}
+	v, _ := pages.LoadOrStore(utils.NormalizeURL(uri), &fetchedPage{})
+	page := v.(*fetchedPage)
+	page.once.Do(func() {
+		page.body, page.ok = linkChecker.Fetch(ctx, utils.NormalizeURL(uri))
+	})
+	if !page.ok || utils.HasAnchor(page.body, u.EscapedFragment()) {
+		return "", false
+	}
+	return fmt.Sprintf("the page has no #%s anchor", u.Fragment), true
+}
+
+// staleInventory builds a warning for a role that an intersphinx inventory resolves to uri, which is stale
+// for reason. The docset builds, but the link it gets is broken until the inventory is updated.
+func staleInventory(role rst.RstRole, uri string, filename string, reason string) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Level = utils.LevelWarning
+	re.Kind = docset.KindStaleInventory
+	re.Filename = filename
+	re.Message = fmt.Sprintf(":%s:`%s` resolves through intersphinx to %s, but %s", role.Name, role.Target, uri, reason)
+	return re
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/sources"
+	"github.com/MongoCaleb/checker/internal/utils"
+)
+
+// ownSiteRole returns the role and target to use instead of link, if link is an absolute link to a page
+// of this docset published at site. A link to a label's anchor becomes a :ref:, and a link to a page a
+// :doc:. Links to other anchors have no equivalent role.
+func ownSiteRole(link string, site string, pages collectors.PageMap, refs collectors.RefTargetMap) (string, string, bool) {
+	p, fragment, ok := sources.SitePath(link, site)
+	if !ok {
+		return "", "", false
+	}
+	docname, ok := pages.Find(p)
+	if !ok {
+		return "", "", false
+	}
+	if fragment == "" {
+		return "doc", docname, true
+	}
+	if label, ok := refs.LabelIn(pages[docname], fragment); ok {
+		return "ref", label, true
+	}
+	return "", "", false
+}
+
+// ownSiteWarning builds a warning for an absolute link to this docset, suggesting the role to use instead.
+func ownSiteWarning(link string, filename string, role string, target string) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Level = utils.LevelWarning
+	re.Kind = sources.KindOwnSite
+	re.Filename = filename
+	re.Message = fmt.Sprintf("%s (links to this docset)\n\rReplace with: :%s:`%s`", link, role, target)
+	return re
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MongoCaleb/checker/internal/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// host is the result of the pre-flight check for one origin, along with every job for a url on it.
+type host struct {
+	origin string
+	err    error
+	cert   *utils.CertificateReport
+	jobs   []job
+}
+
+// preflight checks every host in workStack once, concurrently on the worker pool, and takes the jobs for
+// hosts that can't be reached out of the stack so that a domain that is down costs one connection attempt
+// rather than a timeout per link.
+func preflight(ctx context.Context, workStack []job) ([]job, []host) {
+	byOrigin := make(map[string]int)
+	hosts := make([]host, 0)
+	for _, j := range workStack {
+		origin := utils.Origin(j.url)
+		if origin == "" {
+			continue
+		}
+		i, ok := byOrigin[origin]
+		if !ok {
+			i = len(hosts)
+			byOrigin[origin] = i
+			hosts = append(hosts, host{origin: origin})
+		}
+		hosts[i].jobs = append(hosts[i].jobs, j)
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(h *host) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			h.cert, h.err = linkChecker.Preflight(ctx, h.origin)
+		}(&hosts[i])
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return workStack, nil
+	}
+
+	down := make(map[string]bool)
+	for _, h := range hosts {
+		if h.err != nil {
+			down[h.origin] = true
+		}
+	}
+	if len(down) == 0 {
+		return workStack, hosts
+	}
+
+	remaining := make([]job, 0, len(workStack))
+	for _, j := range workStack {
+		if !down[utils.Origin(j.url)] {
+			remaining = append(remaining, j)
+		}
+	}
+	if loglevel > 0 {
+		log.Warnf("%d hosts could not be reached, %d links on them were not checked.", len(down), len(workStack)-len(remaining))
+	}
+	return remaining, hosts
+}
+
+// affected lists the links on h with the files they are in, and the files on their own.
+func (h host) affected() (string, string) {
+	lines := make([]string, 0, len(h.jobs))
+	files := make([]string, 0)
+	for _, j := range h.jobs {
+		lines = append(lines, fmt.Sprintf("  %s (%s)", j.url, j.filename))
+		if !contains(files, j.filename) {
+			files = append(files, j.filename)
+		}
+	}
+	sort.Strings(lines)
+	sort.Strings(files)
+	return strings.Join(lines, "\n\r"), strings.Join(files, ", ")
+}
+
+// hostUnreachable builds the single error reported for every link on a host that failed its pre-flight check.
+// Its kind is the class of the failure, such as dns-error, so that the class's severity applies.
+func hostUnreachable(h host) utils.HttpResponse {
+	var re utils.HttpResponse
+	links, files := h.affected()
+	kind, reason := utils.ClassifyError(h.err)
+	re.Kind = kind
+	re.Filename = files
+	re.Message = fmt.Sprintf("%s is unreachable: %s\n\rAffected links (%d):\n\r%s", h.origin, reason, len(h.jobs), links)
+	return re
+}
+
+// certificateWarnings builds a warning for each problem with the certificate a host served.
+func certificateWarnings(h host) []utils.HttpResponse {
+	diags := make([]utils.HttpResponse, 0)
+	_, files := h.affected()
+	for _, problem := range h.cert.Problems(time.Now(), CheckerConfig.CertificateExpiryDays()) {
+		var re utils.HttpResponse
+		re.Level = utils.LevelWarning
+		re.Kind = problem.Kind
+		re.Filename = files
+		re.Message = fmt.Sprintf("%s: %s (%d links)", h.origin, problem.Message, len(h.jobs))
+		diags = append(diags, re)
+	}
+	return diags
+}
+
+// printCertificates prints the certificate report section: the chain each https host served, and
+// whether anything is wrong with it.
+func printCertificates(w io.Writer, hosts []host) {
+	now := time.Now()
+	expiryDays := CheckerConfig.CertificateExpiryDays()
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].origin < hosts[j].origin })
+
+	var b strings.Builder
+	b.WriteString("Certificates:\n")
+	for _, h := range hosts {
+		if h.cert == nil {
+			continue
+		}
+		status := "ok"
+		if problems := h.cert.Problems(now, expiryDays); len(problems) > 0 {
+			messages := make([]string, 0, len(problems))
+			for _, problem := range problems {
+				messages = append(messages, problem.Message)
+			}
+			status = strings.Join(messages, "; ")
+		}
+		fmt.Fprintf(&b, "\n%s (%d links): %s\n", h.origin, len(h.jobs), status)
+		for _, cert := range h.cert.Chain {
+			fmt.Fprintf(&b, "  %s\n    issuer: %s\n    expires: %s, key: %s, signature: %s\n",
+				cert.Subject, cert.Issuer, cert.NotAfter.Format("2006-01-02"), cert.Key(), cert.SignatureAlgorithm)
+		}
+	}
+	fmt.Fprint(w, b.String())
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/docset"
+	"github.com/spf13/cobra"
+)
+
+// reportCmd groups reports about the docset itself, which don't check any links
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Reports on the structure of the docset.",
+}
+
+// unusedCmd lists labels, pages and include files that nothing refers to
+var unusedCmd = &cobra.Command{
+	Use:   "unused",
+	Short: "Lists labels no :ref: uses, pages no toctree, :doc: or :ref: reaches, and include files nothing includes.",
+	Long: `Lists labels no :ref: uses, pages that can't be reached from /index through toctrees, :doc: and :ref:
+roles, and include files under source/includes that no include or literalinclude uses.
+
+Shared includes aren't fetched, so labels that only shared content refers to are listed too. Those, labels
+referred to only from other docsets, and pages that are meant to be found some other way, can be
+left out with unused_allow in the config file.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, _ := loadProject()
+		files := collectors.GatherFiles(basepath)
+		printUnused(cmd.OutOrStdout(), docset.FindUnused(files, CheckerConfig.UnusedAllow))
+	},
+}
+
+func init() {
+	reportCmd.AddCommand(unusedCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+// printUnused writes the unused labels, orphan pages and unused include files to w.
+func printUnused(w io.Writer, unused docset.Unused) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d labels are never referenced:\n", len(unused.Labels))
+	for _, label := range unused.Labels {
+		fmt.Fprintf(&b, "  %s (%s)\n", label.Label, strings.Join(label.Locations, ", "))
+	}
+	fmt.Fprintf(&b, "\n%d pages can't be reached from /index:\n", len(unused.Orphans))
+	for _, orphan := range unused.Orphans {
+		fmt.Fprintf(&b, "  %s (%s)\n", orphan.DocName, orphan.Filename)
+	}
+	fmt.Fprintf(&b, "\n%d include files are never included:\n", len(unused.Includes))
+	for _, filename := range unused.Includes {
+		fmt.Fprintf(&b, "  %s\n", filename)
+	}
+	fmt.Fprint(w, b.String())
+}
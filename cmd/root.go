@@ -1,21 +1,28 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/docset"
 	"github.com/MongoCaleb/checker/internal/parsers/intersphinx"
 	"github.com/MongoCaleb/checker/internal/parsers/rst"
 	"github.com/MongoCaleb/checker/internal/sources"
@@ -25,15 +32,32 @@ import (
 )
 
 var (
-	path      string
-	refs      bool
-	docs      bool
-	changes   []string
-	progress  bool
-	workers   int
-	throttle  int
-	loglevel  int
-	LogOutput []utils.HttpResponse
+	path         string
+	refs         bool
+	docs         bool
+	paths        bool
+	directives   bool
+	changes      []string
+	progress     bool
+	workers      int
+	throttle     int
+	loglevel     int
+	timeout      time.Duration
+	record       string
+	replay       string
+	certificates bool
+	checkMX      bool
+	checkFTP     bool
+	spellings    bool
+	inventories  bool
+	LogOutput    []utils.HttpResponse
+
+	linkChecker utils.LinkChecker
+	recorder    *utils.Recorder
+
+	// runCtx is cancelled on interrupt or when --timeout runs out
+	runCtx    context.Context
+	cancelRun context.CancelFunc
 )
 
 type bypassJson struct {
@@ -60,14 +84,26 @@ func loadBypassList(bypassPath string) {
 	}
 }
 
+// CheckerConfig holds the settings from config/link_checker_config.json, if the project has one.
+var CheckerConfig *sources.CheckerConfig
+
+func loadCheckerConfig(configPath string) {
+	byteValue, err := ioutil.ReadFile(filepath.Join(configPath, "config", "link_checker_config.json"))
+	if err != nil && !os.IsNotExist(err) {
+		log.Error(err)
+	}
+	cfg, err := sources.NewCheckerConfig(byteValue)
+	checkErr(err)
+	CheckerConfig = cfg
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:     "checker",
 	Version: "0.2.0",
 	Short:   "Checks links, and optionally :ref:s, :doc:s, and other :role:s in a docs project.",
 
-	Run: func(cmd *cobra.Command, args []string) {
-
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		if val, ok := os.LookupEnv("CHECKER_WORKERS"); ok {
 			v, err := strconv.Atoi(val)
 			if err != nil {
@@ -84,44 +120,65 @@ var rootCmd = &cobra.Command{
 			throttle = v
 		}
 
+		runCtx, cancelRun = context.WithCancel(context.Background())
+		if timeout > 0 {
+			// the timeout is derived from the cancelable context, so that interrupts and the timeout stop
+			// the same run, and cancelling the run releases both
+			var cancelTimeout context.CancelFunc
+			cancel := cancelRun
+			runCtx, cancelTimeout = context.WithTimeout(runCtx, timeout)
+			cancelRun = func() {
+				cancelTimeout()
+				cancel()
+			}
+		}
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupts
+			// a second interrupt gets the default behavior and quits immediately
+			signal.Stop(interrupts)
+			log.Warn("Interrupted, finishing in-flight checks. Interrupt again to quit immediately.")
+			cancelRun()
+		}()
+	},
+
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		cancelRun()
+		if recorder != nil {
+			checkErr(recorder.Save(record))
+			log.Infof("Recorded responses to %s", record)
+		}
+	},
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := runCtx
 		diagnostics := LogOutput
 		diags := make(chan utils.HttpResponse)
+		collected := make(chan struct{})
 		go func() {
 			for d := range diags {
-				diagnostics = append(diagnostics, d)
+				if d, ok := applySeverity(d); ok {
+					diagnostics = append(diagnostics, d)
+				}
 			}
+			close(collected)
 		}()
 
-		type intersphinxResult struct {
-			domain string
-			file   []byte
-		}
-
-		loadBypassList(path)
-		basepath, err := filepath.Abs(path)
-		checkErr(err)
-		snootyToml := utils.GetLocalFile(filepath.Join(path, "snooty.toml"))
-		projectSnooty, err := sources.NewTomlConfig(snootyToml)
-		checkErr(err)
+		basepath, projectSnooty := loadProject()
+		// inventories are kept in the order snooty.toml lists them, so that the first one to define an
+		// object is the one it resolves to, however long each takes to fetch
 		intersphinxes := make([]intersphinx.SphinxMap, len(projectSnooty.Intersphinx))
 		var wgSetup sync.WaitGroup
-		ixs := make(chan intersphinxResult, len(projectSnooty.Intersphinx))
-		for _, intersphinx := range projectSnooty.Intersphinx {
+		for i, phx := range projectSnooty.Intersphinx {
 			wgSetup.Add(1)
-			go func(phx string) {
+			go func(i int, phx string) {
+				defer wgSetup.Done()
 				domain := strings.Split(phx, "objects.inv")[0]
-				file := utils.GetNetworkFile(phx)
-				ixs <- intersphinxResult{domain: domain, file: file}
-			}(intersphinx)
+				intersphinxes[i] = intersphinx.Intersphinx(getNetworkFile(ctx, phx), domain)
+			}(i, phx)
 		}
-		go func() {
-			for res := range ixs {
-				intersphinxes = append(intersphinxes, intersphinx.Intersphinx(res.file, res.domain))
-				wgSetup.Done()
-			}
-		}()
 		wgSetup.Wait()
-		close(ixs)
 		sphinxMap := intersphinx.JoinSphinxes(intersphinxes)
 		files := collectors.GatherFiles(basepath)
 
@@ -129,23 +186,48 @@ var rootCmd = &cobra.Command{
 
 		sharedRefs := make(collectors.RstRoleMap)
 		sharedLocals := make(collectors.RefTargetMap)
+		sharedDirectives := make([]collectors.DirectiveUse, 0)
 
+		fetchedShared := make(map[string]bool)
 		for _, share := range allShared {
-			sharedFile := utils.GetNetworkFile(projectSnooty.SharedPath + share.Path)
+			if fetchedShared[share.Path] {
+				continue
+			}
+			fetchedShared[share.Path] = true
+			sharedFile := getNetworkFile(ctx, projectSnooty.SharedPath+share.Path)
 			sharedRefs.Union(collectors.GatherSharedRefs(sharedFile, *projectSnooty))
-			sharedLocals.Union(collectors.GatherSharedLocalRefs(sharedFile, *projectSnooty))
+			// shared labels are located by the shared file that defines them, so duplicates can be found
+			for label := range collectors.GatherSharedLocalRefs(sharedFile, *projectSnooty) {
+				sharedLocals.Add(label, "shared:"+share.Path)
+			}
+			for _, directive := range rst.ParseForDirectives(sharedFile) {
+				sharedDirectives = append(sharedDirectives, collectors.DirectiveUse{Directive: directive, Filename: "shared:" + share.Path})
+			}
+		}
+
+		// files that weren't fetched before an interrupt or --timeout are empty, and refs resolved against
+		// them would be reported missing
+		fetched := ctx.Err() == nil
+		if refs && !fetched {
+			log.Warn("Intersphinx inventories and shared files were not all fetched, refs will not be checked")
 		}
 
 		allConstants := collectors.GatherConstants(files)
 		allRoleTargets := collectors.GatherRoles(files)
 		allHTTPLinks := collectors.GatherHTTPLinks(files)
-		allLocalRefs := collectors.GatherLocalRefs(files).SSLToTLS()
+		allURILinks := collectors.GatherURILinks(files)
+		allLocalRefs := collectors.GatherLocalRefs(files)
 
 		allRoleTargets.Union(sharedRefs)
 		allLocalRefs.Union(sharedLocals)
+		duplicateLabels := docset.DuplicateLabels(allLocalRefs)
+		allLocalRefs.SSLToTLS()
 
 		allRoleTargets = allRoleTargets.ConvertConstants(projectSnooty)
 
+		if len(changes) == 0 {
+			changes = files
+		}
 		for con, filename := range allConstants {
 			if _, ok := projectSnooty.Constants[con.Name]; !ok {
 				var re utils.HttpResponse
@@ -154,197 +236,248 @@ var rootCmd = &cobra.Command{
 				diags <- re
 			}
 			testCon := rst.RstConstant{Name: con.Name, Target: projectSnooty.Constants[con.Name] + con.Target}
-			if !isBlocked(testCon.Target) && testCon.IsHTTPLink() {
+			if !testCon.IsHTTPLink() {
+				continue
+			}
+			if isBlocked(testCon.Target) {
+				// links that aren't blocked are checked against the policy with the other http links below
+				if contains(changes, strings.TrimPrefix(filename, "/")) {
+					reportPolicy(diags, testCon.Target, filename)
+				}
+			} else {
 				allHTTPLinks[rst.RstHTTPLink(testCon.Target)] = filename
 			}
 		}
 
+		// every url is checked once, by its normalized url, and reported where it is used
 		checkedUrls := sync.Map{}
-		workStack := make([]func(), 0)
-		rstSpecRoles := sources.NewRoleMap(utils.GetNetworkFile(utils.GetLatestSnootyParserTag()))
-
-		if len(changes) == 0 {
-			changes = files
+		check := func(ctx context.Context, url string) *checkedURL {
+			v, _ := checkedUrls.LoadOrStore(utils.NormalizeURL(url), &checkedURL{})
+			checked := v.(*checkedURL)
+			checked.once.Do(func() {
+				checked.resp, checked.ok = linkChecker.Check(ctx, utils.NormalizeURL(url))
+			})
+			return checked
 		}
+		checkJob := func(url string, filename string) job {
+			return job{url: url, filename: filename, run: func(ctx context.Context) bool {
+				checked := check(ctx, url)
+				if ctx.Err() != nil {
+					return false
+				}
+				reportCheck(ctx, diags, url, filename, checked.resp, checked.ok)
+				return true
+			}}
+		}
+		// the urls intersphinx inventories resolve roles to are checked like links, and their anchors too
+		fetchedPages := sync.Map{}
+		inventoryJob := func(role rst.RstRole, url string, filename string) job {
+			return job{url: url, filename: filename, run: func(ctx context.Context) bool {
+				checked := check(ctx, url)
+				if ctx.Err() != nil {
+					return false
+				}
+				if reason, stale := staleReason(ctx, &fetchedPages, url, checked.resp, checked.ok); stale {
+					diags <- staleInventory(role, url, filename, reason)
+				}
+				return true
+			}}
+		}
+		workStack := make([]job, 0)
+		inventoryJobs := make([]job, 0)
+		rstSpecRoles := loadRstSpec(ctx)
+
 		for role, filename := range allRoleTargets {
 			if !contains(changes, strings.TrimPrefix(filename, "/")) {
 				continue
 			}
 
-			switch role.Name {
-			case "guilabel":
+			switch {
+			case role.Name == "guilabel":
+				break
+			case docset.IsRefRole(role.Name):
+				// every use of a ref is resolved below, so that each file using a missing one is reported
 				break
-			case "ref":
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							var re utils.HttpResponse
-							re.Code = -1
-							re.Filename = filename
-							re.Message = fmt.Sprintf("%+v is not a valid ref", role)
-						}
+			case role.Name == "doc":
+				// every use of :doc: is resolved below, since relative targets depend on the file
+				break
+			default:
+				spec, ok := rstSpecRoles.Role(role.Name)
+				if !ok {
+					if len(rstSpecRoles.Roles) > 0 && !rstSpecRoles.RstObjects[role.Name[strings.LastIndex(role.Name, ":")+1:]] {
+						diags <- roleProblem(sources.KindUnknownRole, role, filename, "is not a role in rstspec.toml")
 					}
 					break
 				}
-			case "doc":
-				if docs {
-					if !contains(files, filename) {
-						var re utils.HttpResponse
-						re.Code = -1
-						re.Filename = filename
-						re.Message = fmt.Sprintf("%s is not a valid file found in this docset", role)
-					}
+				if err := spec.CheckTarget(role.Target); err != nil {
+					diags <- roleProblem(sources.KindBadRoleTarget, role, filename, err.Error())
 					break
 				}
-
-			case "py:meth": // this is a fancy magic ref
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							var re utils.HttpResponse
-							re.Code = -1
-							re.Filename = filename
-							re.Message = fmt.Sprintf("%+v is not a valid ref", role)
-						}
-					}
+				url, ok := spec.URL(role.Target)
+				if !ok || isBlocked(spec.Link) {
 					break
 				}
-			case "py:class": // this is a fancy magic ref
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							var re utils.HttpResponse
-							re.Code = -1
-							re.Filename = filename
-							re.Message = fmt.Sprintf("%+v is not a valid ref", role)
-						}
+				if !isBlocked(role.Target) {
+					workStack = append(workStack, checkJob(url, filename))
+				} else {
+					log.Error("roletarget_excluded: ", role.Target)
+				}
+			}
+		}
+
+		if refs && fetched {
+			for _, d := range duplicateLabels {
+				for _, location := range d.Locations {
+					if strings.HasPrefix(location, "shared:") || contains(changes, strings.TrimPrefix(location, "/")) {
+						diags <- duplicateLabel(d)
+						break
 					}
-					break
 				}
-			default:
-				if isBlocked(rstSpecRoles.Roles[role.Name]) {
-					break
+			}
+			resolver := docset.NewResolver(allLocalRefs, sphinxMap)
+			uses := collectors.GatherRoleUses(files, docset.RefRoles...)
+			for role, filename := range sharedRefs {
+				if docset.IsRefRole(role.Name) {
+					uses = append(uses, collectors.RoleUse{Role: role, Filename: filename})
 				}
-				if _, ok := rstSpecRoles.Roles[role.Name]; !ok {
-					if _, ok := rstSpecRoles.RawRoles[role.Name]; !ok {
-						if _, ok := rstSpecRoles.RstObjects[role.Name]; !ok {
-							var re utils.HttpResponse
-							re.Code = -1
-							re.Filename = filename
-							re.Message = fmt.Sprintf(" %s is not a valid role", role)
-						}
-					}
-					break
+			}
+			queued := make(map[string]bool)
+			for _, use := range uses {
+				if !contains(changes, strings.TrimPrefix(use.Filename, "/")) {
+					continue
 				}
-				workFunc := func(role rst.RstRole, filename string) func() {
-					url := fmt.Sprintf(rstSpecRoles.Roles[role.Name], role.Target)
-
-					if _, ok := checkedUrls.Load(url); !ok {
-						return func() {
-							checkedUrls.Store(url, true)
-							if resp, ok := utils.IsReachable(url); !ok {
-								var re utils.HttpResponse
-								re.Code = resp.Code
-								re.Filename = filename
-								re.Message = fmt.Sprintf("%+v", url)
-								diags <- re
-							}
-						}
-					} else {
-						return func() {}
+				use, ok := withConstants(diags, docset.KindRefNotFound, use, projectSnooty.Constants)
+				if !ok {
+					continue
+				}
+				if !resolver.Resolve(use.Role) {
+					diags <- refNotFound(resolver, use)
+				} else if obj, ok := resolver.External(use.Role); ok && inventories && !isBlocked(obj.URL) && !queued[obj.URL+" "+use.Filename] {
+					queued[obj.URL+" "+use.Filename] = true
+					inventoryJobs = append(inventoryJobs, inventoryJob(use.Role, obj.URL, use.Filename))
+				}
+			}
+
+			allDirectives := append(collectors.GatherDirectiveUses(files), sharedDirectives...)
+			objects := docset.NewObjects(rstSpecRoles, allDirectives, sphinxMap)
+			objectRoles := objects.RoleNames()
+			objectUses := collectors.GatherRoleUses(files, objectRoles...)
+			for role, filename := range sharedRefs {
+				for _, name := range objectRoles {
+					if role.Name == name {
+						objectUses = append(objectUses, collectors.RoleUse{Role: role, Filename: filename})
 					}
+				}
+			}
+			for _, use := range objectUses {
+				if !contains(changes, strings.TrimPrefix(use.Filename, "/")) {
+					continue
+				}
+				use, ok := withConstants(diags, docset.KindObjectNotFound, use, projectSnooty.Constants)
+				if !ok {
+					continue
+				}
+				if !objects.Resolve(use.Role) {
+					diags <- objectNotFound(use)
+				}
+			}
+		}
 
+		pages := collectors.GatherPages(files)
+		if docs {
+			for _, use := range collectors.GatherRoleUses(files, "doc") {
+				if !contains(changes, strings.TrimPrefix(use.Filename, "/")) {
+					continue
 				}
+				use, ok := withConstants(diags, collectors.KindDocNotFound, use, projectSnooty.Constants)
+				if !ok {
+					continue
+				}
+				if re, ok := docNotFound(pages, use); ok {
+					diags <- re
+				}
+			}
+		}
 
-				i := isBlocked(role.Target)
-				if !i {
-					workStack = append(workStack, workFunc(role, filename))
-				} else {
-					log.Error("roletarget_excluded: ", role.Target)
+		if paths || directives {
+			uses := make([]collectors.DirectiveUse, 0)
+			for _, use := range collectors.GatherDirectiveUses(files) {
+				if contains(changes, strings.TrimPrefix(use.Filename, "/")) {
+					uses = append(uses, use)
 				}
 			}
+			problems := make([]docset.Problem, 0)
+			if directives {
+				problems = append(problems, docset.CheckDirectives(rstSpecRoles, uses)...)
+			}
+			if paths {
+				problems = append(problems, docset.CheckFiles(basepath, uses, projectSnooty.Constants)...)
+			}
+			for _, p := range problems {
+				diags <- directiveProblem(p)
+			}
 		}
 
 		//At this point, we have all links to check
+		site := CheckerConfig.SiteURL(projectSnooty)
 		for link, filename := range allHTTPLinks {
 			if !contains(changes, strings.TrimPrefix(filename, "/")) {
 				continue
 			}
-			workFunc := func(link rst.RstHTTPLink, filename string) func() {
-				if _, ok := checkedUrls.Load(link); !ok {
-					return func() {
-						checkedUrls.Store(link, true)
-						if resp, ok := utils.IsReachable(string(link)); !ok {
-							var re utils.HttpResponse
-							re.Code = resp.Code
-							re.Filename = filename
-							re.Message = fmt.Sprintf("%s", link)
-							diags <- re
-						}
-					}
-				} else {
-					return func() {}
-				}
+			reportPolicy(diags, string(link), filename)
+			if role, target, ok := ownSiteRole(string(link), site, pages, allLocalRefs); ok {
+				diags <- ownSiteWarning(string(link), filename, role, target)
 			}
-
 			i := isBlocked(string(link))
 			if !i {
-				workStack = append(workStack, workFunc(link, filename))
+				workStack = append(workStack, checkJob(string(link), filename))
 			}
 		}
 
-		jobChannel := make(chan func())
-		doneChannel := make(chan struct{})
-
-		var wgValidate sync.WaitGroup
-		wgValidate.Add(workers)
-		for i := 0; i < workers; i++ {
-			go worker(&wgValidate, jobChannel, doneChannel)
-		}
-
-		bar := pb.StartNew(len(workStack)).SetMaxWidth(120)
-		if loglevel > 0 {
-			log.Info(fmt.Sprintf("Checking %d links", len(workStack)))
-		}
-		if progress && loglevel > 1 {
-			log.Info(progress)
-			bar.SetWriter(os.Stdout)
-		} else {
-			bar.SetWriter(ioutil.Discard)
-		}
-		go func() {
-			for range doneChannel {
-				bar.Increment()
+		schemeChecker := newSchemeChecker()
+		for link, filename := range allURILinks {
+			if !contains(changes, strings.TrimPrefix(filename, "/")) {
+				continue
 			}
-		}()
-
-		for _, f := range workStack {
-			jobChannel <- f
+			reportPolicy(diags, string(link), filename)
+			if isBlocked(string(link)) {
+				continue
+			}
+			workStack = append(workStack, func(link string, filename string) job {
+				return job{url: link, filename: filename, run: func(ctx context.Context) bool {
+					resp, ok := schemeChecker.Check(ctx, link)
+					if ctx.Err() != nil {
+						return false
+					}
+					reportCheck(ctx, diags, link, filename, resp, ok)
+					return true
+				}}
+			}(string(link), filename))
 		}
 
-		close(jobChannel)
-		wgValidate.Wait()
-		bar.Finish()
+		if spellings {
+			printSpellings(cmd.OutOrStdout(), workStack)
+		}
+		workStack = append(workStack, inventoryJobs...)
 
-		if len(diagnostics) > 0 {
-			if len(diagnostics) > 1 {
-				log.Error(len(diagnostics), " errors found.\n")
-			} else {
-				log.Error("1 error found.\n")
-			}
-			sort.Slice(diagnostics, func(i, j int) bool {
-				return diagnostics[i].Code < diagnostics[j].Code
-			})
-			for _, msg := range diagnostics {
-				if loglevel > 0 {
-					log.Error(fmt.Sprintf("\n\r[%d]\n\r%s\n\rSource file: %s", msg.Code, msg.Message, msg.Filename))
-				}
+		workStack, hosts := preflight(ctx, workStack)
+		for _, h := range hosts {
+			if h.err != nil {
+				diags <- hostUnreachable(h)
 			}
-		} else {
-			{
-				log.Info("No errors found.\n")
+			for _, re := range certificateWarnings(h) {
+				diags <- re
 			}
 		}
+		skipped := runJobs(ctx, workStack)
+		reportSkipped(diags, skipped, len(workStack))
+
+		close(diags)
+		<-collected
+		printDiagnostics(diagnostics)
+		if certificates {
+			printCertificates(cmd.OutOrStdout(), hosts)
+		}
 	},
 }
 
@@ -365,10 +498,476 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&path, "path", ".", "path to the project")
 	rootCmd.PersistentFlags().BoolVarP(&refs, "refs", "r", true, "check :refs:")
 	rootCmd.PersistentFlags().BoolVarP(&docs, "docs", "d", true, "check :docs:")
+	rootCmd.PersistentFlags().BoolVar(&paths, "paths", true, "check the files of include, literalinclude, image, figure and input directives")
+	rootCmd.PersistentFlags().BoolVar(&directives, "directives", true, "check directive names, arguments and options against rstspec.toml")
 	rootCmd.PersistentFlags().StringSliceVar(&changes, "changes", []string{}, "The list of files to check")
 	rootCmd.PersistentFlags().BoolVarP(&progress, "progress", "p", true, "show progress bar")
 	rootCmd.PersistentFlags().IntVarP(&workers, "workers", "w", 100, "The number of workers to spawn to do work.")
 	rootCmd.PersistentFlags().IntVarP(&throttle, "throttle", "t", 100, "The throttle factor. Each worker will process at most (1e9 / (throttle / workers)) jobs per second.")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "stop checking after this long, e.g. 10m, and report what was checked so far")
+	rootCmd.PersistentFlags().StringVar(&record, "record", "", "record every response to this file for use with --replay")
+	rootCmd.Flags().BoolVar(&checkMX, "check-mx", false, "check that the domains of mailto: links have mail servers")
+	rootCmd.Flags().BoolVar(&checkFTP, "check-ftp", false, "check that ftp:// links point to a live ftp server")
+	rootCmd.Flags().BoolVar(&inventories, "check-inventories", false, "check that the urls intersphinx inventories resolve :ref: and Python roles to are live, anchors included")
+	rootCmd.Flags().BoolVar(&spellings, "spellings", false, "list urls that are spelled more than one way across the docset")
+	rootCmd.Flags().BoolVar(&certificates, "certificates", false, "print the certificate of every linked https host")
+	rootCmd.PersistentFlags().StringVar(&replay, "replay", "", "answer from a file written by --record instead of the network")
+}
+
+// newLinkChecker sets up linkChecker to replay responses from --replay, or to check the network and
+// record its responses to --record.
+func newLinkChecker() {
+	if replay != "" {
+		replayChecker, err := utils.LoadReplay(replay)
+		checkErr(err)
+		linkChecker = replayChecker
+		return
+	}
+	linkChecker = utils.NewHTTPChecker(CheckerConfig)
+	if record != "" {
+		recorder = utils.NewRecorder(linkChecker)
+		linkChecker = recorder
+	}
+}
+
+// newSchemeChecker sets up the checker for mailto, tel, ftp, git and ssh links. Its network checks can't
+// be recorded, so they are left out when replaying.
+func newSchemeChecker() *utils.SchemeChecker {
+	if replay != "" && (checkMX || checkFTP) {
+		log.Warn("--check-mx and --check-ftp are ignored with --replay")
+		return utils.NewSchemeChecker(false, false)
+	}
+	return utils.NewSchemeChecker(checkMX, checkFTP)
+}
+
+// getNetworkFile fetches a file through linkChecker so that it can be recorded and replayed.
+func getNetworkFile(ctx context.Context, uri string) []byte {
+	body, ok := linkChecker.Fetch(ctx, uri)
+	if !ok && ctx.Err() == nil {
+		log.Errorf("Could not get file %s", uri)
+	}
+	return body
+}
+
+// loadRstSpec fetches rstspec.toml from the latest snooty-parser release. If the run is cancelled first,
+// roles are left unchecked rather than failing the run.
+func loadRstSpec(ctx context.Context) *sources.RstSpec {
+	specURL, err := utils.GetLatestSnootyParserTag(ctx, linkChecker)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Fatal(err)
+		}
+		log.Warn("rstspec.toml was not loaded, roles will not be checked")
+		return sources.NewRoleMap(nil)
+	}
+	return sources.NewRoleMap(getNetworkFile(ctx, specURL))
+}
+
+// loadProject reads the bypass list, checker config and snooty.toml for the project at --path.
+func loadProject() (string, *sources.TomlConfig) {
+	loadBypassList(path)
+	loadCheckerConfig(path)
+	newLinkChecker()
+	basepath, err := filepath.Abs(path)
+	checkErr(err)
+	snootyToml := utils.GetLocalFile(filepath.Join(path, "snooty.toml"))
+	projectSnooty, err := sources.NewTomlConfig(snootyToml)
+	checkErr(err)
+	return basepath, projectSnooty
+}
+
+// checkedURL is the result of checking a url, shared by every spelling of it.
+type checkedURL struct {
+	once sync.Once
+	resp utils.HttpResponse
+	ok   bool
+}
+
+// job is a single check for the worker pool. url and filename identify it in the report if it never runs.
+// run returns false if ctx was cancelled before the check finished.
+type job struct {
+	url      string
+	filename string
+	run      func(ctx context.Context) bool
+}
+
+// runJobs runs jobs on the worker pool, reporting progress as they finish. Once ctx is done no more jobs
+// are dispatched, in-flight jobs are drained, and every job that did not finish is returned.
+func runJobs(ctx context.Context, workStack []job) []job {
+	jobChannel := make(chan job)
+	doneChannel := make(chan struct{})
+
+	var mu sync.Mutex
+	skipped := make([]job, 0)
+	skip := func(j job) {
+		mu.Lock()
+		defer mu.Unlock()
+		skipped = append(skipped, j)
+	}
+
+	var wgValidate sync.WaitGroup
+	wgValidate.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker(ctx, &wgValidate, jobChannel, doneChannel, skip)
+	}
+
+	bar := pb.StartNew(len(workStack)).SetMaxWidth(120)
+	if loglevel > 0 {
+		log.Info(fmt.Sprintf("Checking %d links", len(workStack)))
+	}
+	if progress && loglevel > 1 {
+		log.Info(progress)
+		bar.SetWriter(os.Stdout)
+	} else {
+		bar.SetWriter(ioutil.Discard)
+	}
+	go func() {
+		for range doneChannel {
+			bar.Increment()
+		}
+	}()
+
+dispatch:
+	for i, j := range workStack {
+		select {
+		case jobChannel <- j:
+		case <-ctx.Done():
+			for _, rest := range workStack[i:] {
+				skip(rest)
+			}
+			break dispatch
+		}
+	}
+
+	close(jobChannel)
+	wgValidate.Wait()
+	bar.Finish()
+	return skipped
+}
+
+// reportSkipped marks every job that never finished as skipped, so an interrupted run still gives a
+// complete picture of what was and wasn't checked.
+func reportSkipped(diags chan<- utils.HttpResponse, skipped []job, total int) {
+	if len(skipped) == 0 {
+		return
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		log.Warnf("--timeout of %s reached, %d of %d links were not checked.", timeout, len(skipped), total)
+	} else {
+		log.Warnf("Interrupted, %d of %d links were not checked.", len(skipped), total)
+	}
+	for _, j := range skipped {
+		var re utils.HttpResponse
+		re.Level = utils.LevelWarning
+		re.Kind = utils.KindSkipped
+		re.Filename = j.filename
+		re.Message = j.url
+		diags <- re
+	}
+}
+
+// reportCheck sends the diagnostic, if any, for a url that has been checked.
+func reportCheck(ctx context.Context, diags chan<- utils.HttpResponse, url string, filename string, resp utils.HttpResponse, ok bool) {
+	if !ok {
+		var re utils.HttpResponse
+		re.Code = resp.Code
+		re.Kind = resp.Kind
+		re.Filename = filename
+		re.Message = url
+		if resp.Kind != "" {
+			re.Message = fmt.Sprintf("%s (%s)", url, resp.Message)
+		}
+		re.Redirects = resp.Redirects
+		diags <- re
+	} else if resp.Accepted {
+		diags <- accepted(url, filename, resp)
+	} else if re, ok := soft404(ctx, url, filename, resp); ok {
+		diags <- re
+	} else if re, ok := redirectWarning(url, filename, resp); ok {
+		diags <- re
+	}
+}
+
+// duplicateLabel builds an error for a label defined in more than one place, listing every definition.
+func duplicateLabel(d docset.Duplicate) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Kind = docset.KindDuplicateLabel
+	re.Filename = strings.Join(d.Locations, ", ")
+	re.Message = fmt.Sprintf("the label %s is defined %d times:\n\r%s", d.Label, len(d.Locations), strings.Join(d.Locations, "\n\r"))
+	return re
+}
+
+// directiveProblem builds an error for a directive that doesn't follow rstspec.toml, or whose file doesn't
+// exist or doesn't fit its options. Deprecated directives still build, so they are warnings.
+func directiveProblem(p docset.Problem) utils.HttpResponse {
+	var re utils.HttpResponse
+	if p.Kind == docset.KindDeprecatedDirective {
+		re.Level = utils.LevelWarning
+	}
+	re.Kind = p.Kind
+	re.Filename = p.Filename
+	re.Message = p.Message
+	return re
+}
+
+// roleProblem builds an error of kind for a role that doesn't follow rstspec.toml.
+func roleProblem(kind string, role rst.RstRole, filename string, problem string) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Kind = kind
+	re.Filename = filename
+	re.Message = fmt.Sprintf(":%s:`%s` %s", role.Name, role.Target, problem)
+	return re
+}
+
+// withConstants substitutes the constants in the target of use. A target that uses a constant snooty.toml
+// doesn't define can't be resolved, so it is reported as an error of kind instead, and false is returned.
+func withConstants(diags chan<- utils.HttpResponse, kind string, use collectors.RoleUse, constants map[string]string) (collectors.RoleUse, bool) {
+	target, undefined, ok := docset.SubstituteConstants(use.Role.Target, constants)
+	if !ok {
+		diags <- roleProblem(kind, use.Role, use.Filename, fmt.Sprintf("uses the constant %s, which snooty.toml doesn't define", undefined))
+		return use, false
+	}
+	use.Role.Target = target
+	return use, true
+}
+
+// refNotFound builds an error for a ref role whose target doesn't exist, pointing out where an intersphinx
+// inventory documents it if it is an object of another type.
+func refNotFound(resolver *docset.Resolver, use collectors.RoleUse) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Kind = docset.KindRefNotFound
+	re.Filename = use.Filename
+	re.Message = fmt.Sprintf(":%s:`%s` is not a label in this docset, its shared includes or its intersphinx inventories", use.Role.Name, use.Role.Target)
+	if use.Role.Name != "ref" {
+		re.Message = fmt.Sprintf(":%s:`%s` is not in this docset's intersphinx inventories", use.Role.Name, use.Role.Target)
+	}
+	if typ, obj, ok := resolver.Elsewhere(use.Role); ok {
+		re.Message += fmt.Sprintf("; it is a %s there, at %s", typ, obj.URL)
+	}
+	return re
+}
+
+// objectNotFound builds an error for a role whose target is an object nobody defines.
+func objectNotFound(use collectors.RoleUse) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Kind = docset.KindObjectNotFound
+	re.Filename = use.Filename
+	re.Message = fmt.Sprintf(":%s:`%s` is not defined by a directive in this docset or its shared includes, or in its intersphinx inventories", use.Role.Name, use.Role.Target)
+	return re
+}
+
+// docNotFound builds an error for a :doc: role whose target isn't a page, suggesting the page it may
+// have meant.
+func docNotFound(pages collectors.PageMap, use collectors.RoleUse) (utils.HttpResponse, bool) {
+	var re utils.HttpResponse
+	docname, ok := pages.Resolve(use.Role.Target, use.Filename)
+	if ok {
+		return re, false
+	}
+	re.Kind = collectors.KindDocNotFound
+	re.Filename = use.Filename
+	re.Message = fmt.Sprintf(":doc:`%s` is not a page in this docset (looked for %s)", use.Role.Target, docname)
+	if suggestion, ok := pages.Suggest(docname); ok {
+		re.Message += fmt.Sprintf("; did you mean :doc:`%s`?", suggestion)
+	}
+	return re, true
+}
+
+// reportPolicy reports the leaks in link and every way it breaks the configured policy. Like leaks,
+// policy violations are reported whether or not the link is excluded from checking.
+func reportPolicy(diags chan<- utils.HttpResponse, link string, filename string) {
+	reportLeaks(diags, link, filename)
+	for _, v := range CheckerConfig.Evaluate(link) {
+		var re utils.HttpResponse
+		re.Level, _ = utils.ParseLevel(v.Severity)
+		re.Kind = v.Kind
+		re.Filename = filename
+		re.Message = fmt.Sprintf("%s (%s)", link, v.Message)
+		if v.Suggestion != "" {
+			re.Message += "\n\rReplace with: " + v.Suggestion
+		}
+		diags <- re
+	}
+}
+
+// reportLeaks reports link as an error for everything it gives away that shouldn't be published, whether
+// or not it is excluded from checking. The link is redacted in the report.
+func reportLeaks(diags chan<- utils.HttpResponse, link string, filename string) {
+	for _, leak := range utils.FindLeaks(link, CheckerConfig) {
+		var re utils.HttpResponse
+		re.Kind = leak.Kind
+		re.Filename = filename
+		re.Message = fmt.Sprintf("%s (%s)", utils.Redact(link, CheckerConfig), leak.Message)
+		diags <- re
+	}
+}
+
+// applySeverity gives d the severity configured for its kind, and reports false if that kind is ignored.
+func applySeverity(d utils.HttpResponse) (utils.HttpResponse, bool) {
+	severity, ok := CheckerConfig.SeverityFor(d.Kind)
+	if !ok {
+		return d, true
+	}
+	if severity == sources.SeverityIgnore {
+		return d, false
+	}
+	if level, ok := utils.ParseLevel(severity); ok {
+		d.Level = level
+	}
+	return d, true
+}
+
+func printDiagnostics(diagnostics []utils.HttpResponse) {
+	errCount, warnCount, infoCount := 0, 0, 0
+	for _, d := range diagnostics {
+		switch d.Level {
+		case utils.LevelWarning:
+			warnCount++
+		case utils.LevelInfo:
+			infoCount++
+		default:
+			errCount++
+		}
+	}
+	if errCount == 0 && warnCount == 0 {
+		log.Info("No errors found.\n")
+	}
+	if errCount == 1 {
+		log.Error("1 error found.\n")
+	} else if errCount > 1 {
+		log.Error(errCount, " errors found.\n")
+	}
+	if warnCount == 1 {
+		log.Warn("1 warning found.\n")
+	} else if warnCount > 1 {
+		log.Warn(warnCount, " warnings found.\n")
+	}
+	if infoCount > 0 && loglevel > 1 {
+		log.Info(infoCount, " links accepted with a non-200 status.\n")
+	}
+	sort.Slice(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Level != diagnostics[j].Level {
+			return diagnostics[i].Level < diagnostics[j].Level
+		}
+		return diagnostics[i].Code < diagnostics[j].Code
+	})
+	for _, msg := range diagnostics {
+		if loglevel > 0 {
+			code := strconv.Itoa(msg.Code)
+			if msg.Kind != "" && msg.Code == 0 {
+				code = msg.Kind
+			} else if msg.Kind != "" {
+				code += " " + msg.Kind
+			}
+			out := fmt.Sprintf("\n\r[%s]\n\r%s\n\rSource file: %s", code, msg.Message, msg.Filename)
+			switch msg.Level {
+			case utils.LevelWarning:
+				log.Warn(out)
+			case utils.LevelInfo:
+				if loglevel > 1 {
+					log.Info(out)
+				}
+			default:
+				log.Error(out)
+			}
+		}
+	}
+}
+
+// printSpellings lists every page that is linked to with more than one spelling, such as with and without
+// a trailing slash or tracking parameters, so that the links can be made consistent. Links to different
+// fragments of a page are not different spellings.
+func printSpellings(w io.Writer, jobs []job) {
+	variants := make(map[string]map[string][]string)
+	for _, j := range jobs {
+		if utils.Origin(j.url) == "" {
+			continue
+		}
+		key := utils.URLKey(j.url)
+		spelling := j.url
+		if i := strings.Index(spelling, "#"); i >= 0 {
+			spelling = spelling[:i]
+		}
+		if variants[key] == nil {
+			variants[key] = make(map[string][]string)
+		}
+		if !contains(variants[key][spelling], j.filename) {
+			variants[key][spelling] = append(variants[key][spelling], j.filename)
+		}
+	}
+
+	keys := make([]string, 0)
+	for key, spelled := range variants {
+		if len(spelled) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d urls are spelled more than one way:\n", len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n%s\n", key)
+		spelled := make([]string, 0, len(variants[key]))
+		for spelling := range variants[key] {
+			spelled = append(spelled, spelling)
+		}
+		sort.Strings(spelled)
+		for _, spelling := range spelled {
+			files := variants[key][spelling]
+			sort.Strings(files)
+			fmt.Fprintf(&b, "  %s (%s)\n", spelling, strings.Join(files, ", "))
+		}
+	}
+	fmt.Fprint(w, b.String())
+}
+
+// redirectWarning builds a warning for a reachable url whose redirect chain should be updated in the source.
+func redirectWarning(url string, filename string, resp utils.HttpResponse) (utils.HttpResponse, bool) {
+	var re utils.HttpResponse
+	reasons := resp.RedirectReasons()
+	if len(reasons) == 0 {
+		return re, false
+	}
+	re.Code = resp.Redirects[0].Code
+	re.Level = utils.LevelWarning
+	re.Kind = utils.KindRedirect
+	re.Filename = filename
+	re.Redirects = resp.Redirects
+	re.Message = fmt.Sprintf("%s (%s)\n\rReplace with: %s\n\rChain: %s", url, strings.Join(reasons, ", "), resp.FinalURL(), resp.Chain())
+	return re, true
+}
+
+// accepted records that a url's non-200 status was accepted by its host settings, so that it is not silently
+// treated like a 200.
+func accepted(url string, filename string, resp utils.HttpResponse) utils.HttpResponse {
+	var re utils.HttpResponse
+	re.Code = resp.Code
+	re.Level = utils.LevelInfo
+	re.Kind = utils.KindAccepted
+	re.Filename = filename
+	re.Message = fmt.Sprintf("%s (status %d accepted by host settings)", url, resp.Code)
+	return re
+}
+
+// soft404 builds a diagnostic for a reachable url that the configured soft-404 heuristics for its host
+// identify as a missing page.
+func soft404(ctx context.Context, uri string, filename string, resp utils.HttpResponse) (utils.HttpResponse, bool) {
+	var re utils.HttpResponse
+	u, err := url.Parse(uri)
+	if err != nil {
+		return re, false
+	}
+	reason, ok := utils.Soft404(ctx, linkChecker, uri, resp, CheckerConfig.Soft404For(u.Hostname()))
+	if !ok {
+		return re, false
+	}
+	re.Code = resp.Code
+	re.Kind = utils.KindSoft404
+	re.Filename = filename
+	re.Redirects = resp.Redirects
+	re.Message = fmt.Sprintf("%s (%s)", uri, reason)
+	return re, true
 }
 
 func checkErr(err error) {
@@ -400,17 +999,22 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func worker(wg *sync.WaitGroup, jobChannel <-chan func(), doneChannel chan<- struct{}) {
+func worker(ctx context.Context, wg *sync.WaitGroup, jobChannel <-chan job, doneChannel chan<- struct{}, skip func(job)) {
 	defer wg.Done()
 	lastExecutionTime := time.Now()
 	minimumTimeBetweenEachExecution := time.Duration(math.Ceil(1e9 / (float64(throttle) / float64(workers))))
-	for job := range jobChannel {
+	for j := range jobChannel {
 		timeUntilNextExecution := -(time.Since(lastExecutionTime) - minimumTimeBetweenEachExecution)
 		if timeUntilNextExecution > 0 {
-			time.Sleep(timeUntilNextExecution)
+			select {
+			case <-time.After(timeUntilNextExecution):
+			case <-ctx.Done():
+			}
 		}
 		lastExecutionTime = time.Now()
-		job()
+		if ctx.Err() != nil || !j.run(ctx) {
+			skip(j)
+		}
 		doneChannel <- struct{}{}
 	}
 }
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MongoCaleb/checker/internal/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	snootyParserTags = "https://api.github.com/repos/mongodb/snooty-parser/tags"
+	rstSpecURL       = "https://raw.githubusercontent.com/mongodb/snooty-parser/v0.1/snooty/rstspec.toml"
+)
+
+// body returns s as the body of a recording.
+func body(s string) *[]byte {
+	b := []byte(s)
+	return &b
+}
+
+// inventory returns an objects.inv with the lines of a version 2 inventory as the body of a recording.
+func inventory(lines ...string) *[]byte {
+	var buf bytes.Buffer
+	buf.WriteString("# Sphinx inventory version 2\n# Project: MongoDB\n# Version: 7.0\n")
+	buf.WriteString("# The remainder of this file is compressed using zlib.\n")
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	w.Close()
+	b := buf.Bytes()
+	return &b
+}
+
+// replayFile writes recordings to a replay file, along with the rstspec.toml every run loads.
+func replayFile(t *testing.T, rstspec string, recordings utils.Recordings) string {
+	recordings[snootyParserTags] = &utils.Recording{Code: 200, OK: true, Body: body(`[{"name": "v0.1"}]`)}
+	recordings[rstSpecURL] = &utils.Recording{Code: 200, OK: true, Body: body(rstspec)}
+	out, err := json.Marshal(recordings)
+	assert.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "replay.json")
+	assert.NoError(t, ioutil.WriteFile(filename, out, 0644))
+	return filename
+}
+
+// resetFlags puts every flag back to its default, since the flags are package variables that outlive a
+// run of the command.
+func resetFlags() {
+	for _, flags := range []*pflag.FlagSet{rootCmd.Flags(), rootCmd.PersistentFlags()} {
+		flags.VisitAll(func(f *pflag.Flag) {
+			if _, ok := f.Value.(pflag.SliceValue); !ok {
+				f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		})
+	}
+	changes = nil
+	BypassList = nil
+}
+
+// runChecker runs checker with args, as from the command line, and returns the diagnostics it reports as
+// "level [code] filename: message", sorted.
+func runChecker(t *testing.T, args ...string) []string {
+	resetFlags()
+	defer resetFlags()
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	log.SetOutput(ioutil.Discard)
+
+	rootCmd.SetArgs(append([]string{"--progress=false", "--loglevel=1"}, args...))
+	assert.NoError(t, rootCmd.Execute())
+
+	diagnostics := make([]string, 0)
+	for _, entry := range hook.AllEntries() {
+		parts := strings.Split(entry.Message, "\n\r")
+		if len(parts) < 4 || !strings.HasPrefix(parts[1], "[") {
+			continue
+		}
+		filename := strings.TrimPrefix(parts[len(parts)-1], "Source file: ")
+		message := strings.Join(parts[2:len(parts)-1], " ")
+		diagnostics = append(diagnostics, fmt.Sprintf("%s %s %s: %s", entry.Level, parts[1], filename, message))
+	}
+	sort.Strings(diagnostics)
+	return diagnostics
+}
+
+func TestReplayLinks(t *testing.T) {
+	replay := replayFile(t, "", utils.Recordings{
+		"https://example.com/docs/":         {Code: 200, OK: true},
+		"https://example.com/docs/missing/": {Code: 404, OK: false},
+		"https://example.com/docs/old/": {Code: 200, OK: true, Redirects: []utils.Redirect{
+			{Code: 301, From: "https://example.com/docs/old/", To: "https://example.com/docs/new/"},
+		}},
+		"https://example.com/docs/guides": {Code: 200, OK: true, Redirects: []utils.Redirect{
+			{Code: 301, From: "https://example.com/docs/guides", To: "https://example.com/docs/guides/"},
+		}},
+		"https://example.com/docs/guides/": {Code: 200, OK: true},
+		"https://mirror.example.net:443":   {Unreachable: "the domain mirror.example.net does not exist", UnreachableKind: utils.KindDNS},
+		"https://staging.example.org:443": {Certificate: &utils.CertificateReport{
+			Host:        "staging.example.org",
+			Chain:       []utils.Certificate{{Subject: "CN=staging.example.org", NotAfter: time.Now().AddDate(1, 0, 0), KeyAlgorithm: "Ed25519"}},
+			VerifyError: "TLS certificate is signed by an unknown authority",
+		}},
+		"https://staging.example.org/docs/": {Code: 200, OK: true},
+	})
+
+	assert.Equal(t, []string{
+		"error [404] /source/index.txt: https://example.com/docs/missing/",
+		"error [not-recorded] /source/index.txt: https://example.com/docs/unrecorded/ (not in the replay file)",
+		// the spelling that redirects is reported, and the one it redirects to isn't
+		"warning [301 redirect] /source/index.txt: https://example.com/docs/guides (permanent redirect) " +
+			"Replace with: https://example.com/docs/guides/ Chain: https://example.com/docs/guides -[301]-> https://example.com/docs/guides/",
+		"warning [301 redirect] /source/index.txt: https://example.com/docs/old/ (permanent redirect) " +
+			"Replace with: https://example.com/docs/new/ Chain: https://example.com/docs/old/ -[301]-> https://example.com/docs/new/",
+		// the config makes dns errors warnings, and that applies to hosts that fail the pre-flight check
+		"warning [dns-error] /source/index.txt: https://mirror.example.net:443 is unreachable: the domain mirror.example.net " +
+			"does not exist Affected links (1):   https://mirror.example.net/docs/ (/source/index.txt)",
+		// the links on a host with an untrusted certificate are still checked
+		"warning [untrusted-certificate] /source/index.txt: https://staging.example.org:443: TLS certificate is signed by an unknown authority (1 links)",
+	}, runChecker(t, "--path", "testdata/links", "--replay", replay))
+}
+
+// manualInventory is the recording of the inventory testdata/refs uses.
+var manualInventory = &utils.Recording{Code: 200, OK: true, Body: inventory(
+	"aggregation-pipeline std:label -1 core/aggregation-pipeline/#$ Aggregation Pipeline",
+)}
+
+func TestReplayRefs(t *testing.T) {
+	replay := replayFile(t, "", utils.Recordings{
+		"https://www.mongodb.com/docs/manual/objects.inv": manualInventory,
+	})
+
+	assert.Equal(t, []string{
+		"error [doc-not-found] /source/index.txt: :doc:`/tutorial/uninstall` is not a page in this docset (looked for /tutorial/uninstall); did you mean :doc:`/tutorial/install`?",
+		"error [ref-not-found] /source/index.txt: :ref:`no-such-label` is not a label in this docset, its shared includes or its intersphinx inventories",
+		"error [ref-not-found] /source/index.txt: :ref:`{+version+}-intro` uses the constant version, which snooty.toml doesn't define",
+		"error [ref-not-found] /source/tutorial/install.txt: :ref:`refs-uninstall` is not a label in this docset, its shared includes or its intersphinx inventories",
+	}, runChecker(t, "--path", "testdata/refs", "--replay", replay))
+}
+
+func TestReplayRefsAfterTimeout(t *testing.T) {
+	replay := replayFile(t, "", utils.Recordings{
+		"https://www.mongodb.com/docs/manual/objects.inv": manualInventory,
+	})
+
+	// the inventory may be missing once the run is out of time, so no ref is reported missing, while
+	// :doc: targets only depend on the docset and are still checked
+	assert.Equal(t, []string{
+		"error [doc-not-found] /source/index.txt: :doc:`/tutorial/uninstall` is not a page in this docset (looked for /tutorial/uninstall); did you mean :doc:`/tutorial/install`?",
+	}, runChecker(t, "--path", "testdata/refs", "--replay", replay, "--timeout", "1ns"))
+}
+
+func TestReplayInventories(t *testing.T) {
+	replay := replayFile(t, "", utils.Recordings{
+		"https://www.mongodb.com/docs/manual/objects.inv": {Code: 200, OK: true, Body: inventory(
+			"aggregation-pipeline std:label -1 core/aggregation-pipeline/#$ Aggregation Pipeline",
+			"removed-page std:label -1 core/removed/#$ Removed Page",
+			"renamed-section std:label -1 core/aggregation-pipeline/#$ Renamed Section",
+		)},
+		"https://www.mongodb.com/docs/manual/core/aggregation-pipeline/": {Code: 200, OK: true,
+			Body: body(`<html><body><section id="aggregation-pipeline"><h1>Aggregation Pipeline</h1></section></body></html>`)},
+		"https://www.mongodb.com/docs/manual/core/removed/": {Code: 404, OK: false},
+		// a later inventory that also has removed-page doesn't replace the earlier one's
+		"https://www.mongodb.com/docs/atlas/objects.inv": {Code: 200, OK: true, Body: inventory(
+			"removed-page std:label -1 removed/#$ Removed Page",
+		)},
+		"https://www.mongodb.com/docs/atlas/removed/": {Code: 200, OK: true, Body: body(`<h1 id="removed-page">Removed Page</h1>`)},
+	})
+
+	assert.Equal(t, []string{
+		"warning [stale-inventory] /source/index.txt: :ref:`removed-page` resolves through intersphinx to " +
+			"https://www.mongodb.com/docs/manual/core/removed/#removed-page, but it answers 404",
+		"warning [stale-inventory] /source/index.txt: :ref:`renamed-section` resolves through intersphinx to " +
+			"https://www.mongodb.com/docs/manual/core/aggregation-pipeline/#renamed-section, but the page has no #renamed-section anchor",
+	}, runChecker(t, "--path", "testdata/inventories", "--replay", replay, "--check-inventories"))
+
+	// without the flag the inventory urls aren't checked
+	assert.Empty(t, runChecker(t, "--path", "testdata/inventories", "--replay", replay))
+}
+
+func TestReplayChanges(t *testing.T) {
+	replay := replayFile(t, "", utils.Recordings{
+		"https://example.com/docs/": {Code: 200, OK: true},
+	})
+
+	assert.Equal(t, []string{
+		"error [private-host] /source/index.txt: http://localhost:8080/docs/ (links to localhost, which readers can't reach)",
+	}, runChecker(t, "--path", "testdata/changes", "--replay", replay))
+
+	// leaks in blocked constant links are only reported for the files that changed, like other links
+	assert.Empty(t, runChecker(t, "--path", "testdata/changes", "--replay", replay, "--changes", "source/changed.txt"))
+}
@@ -138,18 +138,41 @@ func GatherHTTPLinks(files []string) map[rst.RstHTTPLink]string {
 	return links
 }
 
-type RefTargetMap map[rst.RefTarget]string
+// GatherURILinks collects mailto, tel, ftp, git and ssh links.
+func GatherURILinks(files []string) map[rst.RstURILink]string {
+	links := make(map[rst.RstURILink]string, len(files))
+	gather(files, func(filename string, data []byte) {
+		for _, link := range rst.ParseForURILinks(data) {
+			links[link] = filename
+		}
+	})
+	return links
+}
+
+// RefTargetMap maps each label to every file that defines it, so that duplicates can be found.
+type RefTargetMap map[rst.RefTarget][]string
 
 func GatherLocalRefs(files []string) RefTargetMap {
-	refs := make(map[rst.RefTarget]string, len(files))
+	refs := make(RefTargetMap, len(files))
 	gather(files, func(filename string, data []byte) {
 		for _, ref := range rst.ParseForLocalRefs(data) {
-			refs[ref] = filename
+			refs.Add(ref, filename)
 		}
 	})
 	return refs
 }
 
+// Add records that filename defines ref. A file is only recorded once per label, so a label in an
+// include file counts as one definition however many pages include it.
+func (r RefTargetMap) Add(ref rst.RefTarget, filename string) {
+	for _, f := range r[ref] {
+		if f == filename {
+			return
+		}
+	}
+	r[ref] = append(r[ref], filename)
+}
+
 func (r *RefTargetMap) Get(ref *rst.RstRole) (*rst.RefTarget, bool) {
 	for k := range *r {
 		if k.Name == ref.Target {
@@ -160,8 +183,10 @@ func (r *RefTargetMap) Get(ref *rst.RstRole) (*rst.RefTarget, bool) {
 }
 
 func (r *RefTargetMap) Union(other RefTargetMap) *RefTargetMap {
-	for k, v := range other {
-		(*r)[k] = v
+	for k, files := range other {
+		for _, f := range files {
+			r.Add(k, f)
+		}
 	}
 	return r
 }
@@ -171,7 +196,9 @@ func (r RefTargetMap) SSLToTLS() RefTargetMap {
 	for k, v := range r {
 		if strings.Contains(k.Name, "ssl") {
 			tlsK := rst.RefTarget{Name: strings.Replace(k.Name, "ssl", "tls", 1)}
-			r[tlsK] = v
+			for _, f := range v {
+				r.Add(tlsK, f)
+			}
 		}
 	}
 	return r
@@ -200,7 +227,7 @@ func GatherSharedRefs(input []byte, defs sources.TomlConfig) RstRoleMap {
 }
 
 func GatherSharedLocalRefs(input []byte, defs sources.TomlConfig) RefTargetMap {
-	refs := make(map[rst.RefTarget]string, len(input))
+	refs := make(RefTargetMap, len(input))
 	for _, ref := range rst.ParseForLocalRefs(input) {
 		allFound := sharedConstantRegex.FindAllString(ref.Name, -1)
 		for _, match := range allFound {
@@ -208,7 +235,7 @@ func GatherSharedLocalRefs(input []byte, defs sources.TomlConfig) RefTargetMap {
 				ref.Name = strings.Replace(ref.Name, inner[0], defs.Constants[inner[1]], 1)
 			}
 		}
-		refs[ref] = "shared"
+		refs.Add(ref, "shared")
 	}
 	return refs
 }
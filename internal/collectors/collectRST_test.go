@@ -16,19 +16,19 @@ import (
 )
 
 var (
-	//go:embed testdata/index.txt
+	//go:embed testdata/source/index.txt
 	indexFile []byte
 
-	//go:embed testdata/aggregation.txt
+	//go:embed testdata/source/aggregation.txt
 	aggregationsFile []byte
 
-	//go:embed testdata/gridfs.txt
+	//go:embed testdata/source/gridfs.txt
 	grifsFile []byte
 
-	//go:embed testdata/compatibility.txt
+	//go:embed testdata/source/compatibility.txt
 	compatibilityFile []byte
 
-	//go:embed testdata/about-compatibility.rst
+	//go:embed testdata/source/about-compatibility.rst
 	sharedFile []byte
 
 	//go:embed testdata/snooty.toml
@@ -150,6 +150,8 @@ func TestGatherRoles(t *testing.T) {
 		{Target: "gridfs-rename-files", RoleType: "ref", Name: "ref"}:                                         "/source/fundamentals/gridfs.txt",
 		{Target: "gridfs-retrieve-file-info", RoleType: "ref", Name: "ref"}:                                   "/source/fundamentals/gridfs.txt",
 		{Target: "gridfs-upload-files", RoleType: "ref", Name: "ref"}:                                         "/source/fundamentals/gridfs.txt",
+		{Target: "package/this-is-a-bad-link/", RoleType: "role", Name: "npm"}:                                "/source/fundamentals/gridfs.txt",
+		{Target: "package/@realm/react", RoleType: "role", Name: "npm"}:                                       "/source/fundamentals/gridfs.txt",
 	}
 
 	actual := GatherRoles(GatherFiles(basepath))
@@ -253,14 +255,14 @@ func TestRefTargetMapGet(t *testing.T) {
 	}
 
 	localRefs := RefTargetMap{
-		{Name: "gridfs-create-bucket"}:        "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-bucket"}:        "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-download-files"}:       "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-rename-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-retrieve-file-info"}:   "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-upload-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "nodejs-aggregation-overview"}: "/source/fundamentals/aggregation.txt",
+		{Name: "gridfs-create-bucket"}:        {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-bucket"}:        {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-download-files"}:       {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-rename-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-retrieve-file-info"}:   {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-upload-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "nodejs-aggregation-overview"}: {"/source/fundamentals/aggregation.txt"},
 	}
 	for _, target := range targets {
 		_, ok := localRefs.Get(&target)
@@ -270,19 +272,19 @@ func TestRefTargetMapGet(t *testing.T) {
 
 func TestRefTargetMapUnion(t *testing.T) {
 	lr1 := RefTargetMap{
-		{Name: "gridfs-create-bucket"}: "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-bucket"}: "/source/fundamentals/gridfs.txt",
+		{Name: "gridfs-create-bucket"}: {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-bucket"}: {"/source/fundamentals/gridfs.txt"},
 	}
 
 	lr2 := RefTargetMap{
-		{Name: "gridfs-delete-files"}:   "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-download-files"}: "/source/fundamentals/gridfs.txt",
+		{Name: "gridfs-delete-files"}:   {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-download-files"}: {"/source/fundamentals/gridfs.txt"},
 	}
 	expected := RefTargetMap{
-		{Name: "gridfs-create-bucket"}:  "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-bucket"}:  "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-files"}:   "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-download-files"}: "/source/fundamentals/gridfs.txt",
+		{Name: "gridfs-create-bucket"}:  {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-bucket"}:  {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-files"}:   {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-download-files"}: {"/source/fundamentals/gridfs.txt"},
 	}
 
 	assert.EqualValues(t, &expected, lr1.Union(lr2), "union should return union of two maps")
@@ -291,12 +293,12 @@ func TestRefTargetMapUnion(t *testing.T) {
 
 func TestRefTargetMapSSLToTLS(t *testing.T) {
 	lr1 := RefTargetMap{
-		{Name: "nodejs-ssl"}: "/source/fundamentals/ssl.txt",
+		{Name: "nodejs-ssl"}: {"/source/fundamentals/ssl.txt"},
 	}
 
 	expected := RefTargetMap{
-		{Name: "nodejs-ssl"}: "/source/fundamentals/ssl.txt",
-		{Name: "nodejs-tls"}: "/source/fundamentals/ssl.txt",
+		{Name: "nodejs-ssl"}: {"/source/fundamentals/ssl.txt"},
+		{Name: "nodejs-tls"}: {"/source/fundamentals/ssl.txt"},
 	}
 
 	assert.EqualValues(t, expected, lr1.SSLToTLS(), "union should return union of two maps")
@@ -355,14 +357,14 @@ func TestGatherLocalRefs(t *testing.T) {
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "gridfs.txt"), []byte(grifsFile), 0644))
 
 	expected := RefTargetMap{
-		{Name: "gridfs-create-bucket"}:        "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-bucket"}:        "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-delete-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-download-files"}:       "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-rename-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-retrieve-file-info"}:   "/source/fundamentals/gridfs.txt",
-		{Name: "gridfs-upload-files"}:         "/source/fundamentals/gridfs.txt",
-		{Name: "nodejs-aggregation-overview"}: "/source/fundamentals/aggregation.txt",
+		{Name: "gridfs-create-bucket"}:        {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-bucket"}:        {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-delete-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-download-files"}:       {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-rename-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-retrieve-file-info"}:   {"/source/fundamentals/gridfs.txt"},
+		{Name: "gridfs-upload-files"}:         {"/source/fundamentals/gridfs.txt"},
+		{Name: "nodejs-aggregation-overview"}: {"/source/fundamentals/aggregation.txt"},
 	}
 
 	actual := GatherLocalRefs(GatherFiles(basepath))
@@ -391,6 +393,7 @@ func TestGatherSharedRefs(t *testing.T) {
 	expected := RstRoleMap{
 		{Target: "mongodb-compatibility-table-about-node", RoleType: "ref", Name: "ref"}:  "shared",
 		{Target: "language-compatibility-table-about-node", RoleType: "ref", Name: "ref"}: "shared",
+		{Target: "package/@realm/react", RoleType: "role", Name: "npm"}:                   "shared",
 	}
 
 	sampleCfg, err := sources.NewTomlConfig(snootyToml)
@@ -406,8 +409,8 @@ func TestGatherSharedRefs(t *testing.T) {
 
 func TestGatherSharedLocalRefs(t *testing.T) {
 	expected := RefTargetMap{
-		{Name: "mongodb-compatibility-table-about-node"}:  "shared",
-		{Name: "language-compatibility-table-about-node"}: "shared",
+		{Name: "mongodb-compatibility-table-about-node"}:  {"shared"},
+		{Name: "language-compatibility-table-about-node"}: {"shared"},
 	}
 
 	sampleCfg, err := sources.NewTomlConfig(snootyToml)
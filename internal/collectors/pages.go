@@ -0,0 +1,242 @@
+package collectors
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+)
+
+// KindDocNotFound marks :doc: roles whose target isn't a page of the docset.
+const KindDocNotFound = "doc-not-found"
+
+// RoleUse is a role where it is used. Unlike RstRoleMap, which keeps one file per role, every use of a
+// role is kept, since a relative target means something different in each file.
+type RoleUse struct {
+	Role     rst.RstRole
+	Filename string
+}
+
+// PageMap maps the docname of each page, such as /tutorial/install for source/tutorial/install.txt, to
+// the page's filename.
+type PageMap map[string]string
+
+// pageExts are the extensions of files that Snooty builds into pages.
+var pageExts = []string{".txt", ".rst"}
+
+// DocName returns the docname of a gathered file, or false if the file isn't a page: it must be under
+// source/, outside source/includes/, and have a page extension.
+func DocName(filename string) (string, bool) {
+	rel := strings.TrimPrefix(filename, "/")
+	if !strings.HasPrefix(rel, "source/") || strings.HasPrefix(rel, "source/includes/") {
+		return "", false
+	}
+	ext := path.Ext(rel)
+	for _, pageExt := range pageExts {
+		if ext == pageExt {
+			return "/" + strings.TrimSuffix(strings.TrimPrefix(rel, "source/"), ext), true
+		}
+	}
+	return "", false
+}
+
+// GatherPages builds the PageMap of files.
+func GatherPages(files []string) PageMap {
+	pages := make(PageMap, len(files))
+	for _, file := range files {
+		filename := strings.Replace(file, basepath, "", 1)
+		if docname, ok := DocName(filename); ok {
+			pages[docname] = filename
+		}
+	}
+	return pages
+}
+
+// GatherIncludeFiles returns the filenames of the files under source/includes/ that can be included
+// as reStructuredText, which have a page extension.
+func GatherIncludeFiles(files []string) []string {
+	includes := make([]string, 0)
+	for _, file := range files {
+		filename := strings.Replace(file, basepath, "", 1)
+		if strings.HasPrefix(filename, "/source/includes/") && containsName(pageExts, path.Ext(filename)) {
+			includes = append(includes, filename)
+		}
+	}
+	return includes
+}
+
+// GatherRoleUses returns every use of the roles named names in files.
+func GatherRoleUses(files []string, names ...string) []RoleUse {
+	uses := make([]RoleUse, 0)
+	gather(files, func(filename string, data []byte) {
+		for _, role := range rst.ParseForRoles(data) {
+			if containsName(names, role.Name) {
+				uses = append(uses, RoleUse{Role: role, Filename: filename})
+			}
+		}
+	})
+	return uses
+}
+
+// DirectiveUse is a directive where it is used, such as an include and the file it is in. Each entry of a
+// toctree is a use of its own, with the entry as the Target and the toctree's Options.
+type DirectiveUse struct {
+	Directive rst.RstDirective
+	Filename  string
+}
+
+// GatherDirectiveUses returns every use of the directives named names in files, or of every directive
+// if no names are given. Toctree entries are only returned if toctree is named.
+func GatherDirectiveUses(files []string, names ...string) []DirectiveUse {
+	uses := make([]DirectiveUse, 0)
+	gather(files, func(filename string, data []byte) {
+		for _, directive := range rst.ParseForDirectives(data) {
+			if len(names) == 0 || containsName(names, directive.Name) {
+				directive.Target = strings.TrimSpace(directive.Target)
+				uses = append(uses, DirectiveUse{Directive: directive, Filename: filename})
+			}
+		}
+		if containsName(names, "toctree") {
+			for _, toctree := range rst.ParseForToctrees(data) {
+				for _, entry := range toctree.Entries {
+					directive := rst.RstDirective{Name: "toctree", Target: entry, Options: toctree.Options}
+					uses = append(uses, DirectiveUse{Directive: directive, Filename: filename})
+				}
+			}
+		}
+	})
+	return uses
+}
+
+// Resolve returns the docname a :doc: target used in filename refers to, the way Snooty resolves it: an
+// absolute target is relative to source/, and any other is relative to the directory of filename. An
+// extension or a trailing slash may be written, and a directory stands for its index page. The target it
+// would refer to is returned along with false if there is no such page.
+func (pages PageMap) Resolve(target string, filename string) (string, bool) {
+	docname := absDocName(target, filename)
+	if found, ok := pages.Find(strings.Trim(docname, "/")); ok {
+		return found, true
+	}
+	return docname, false
+}
+
+// Glob returns the docnames of the pages that the entry pattern of a :glob: toctree in filename matches,
+// sorted. The pattern is relative like a :doc: target, * and ? don't match a /, and the page the toctree is
+// on is left out, as Snooty does.
+func (pages PageMap) Glob(pattern string, filename string) []string {
+	pattern = absDocName(pattern, filename)
+	matched := make([]string, 0)
+	for docname, file := range pages {
+		if ok, err := path.Match(pattern, docname); err == nil && ok && file != filename {
+			matched = append(matched, docname)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// absDocName returns the absolute docname a target used in filename spells, without its extension.
+func absDocName(target string, filename string) string {
+	docname := strings.Join(strings.Fields(target), "")
+	for _, ext := range pageExts {
+		docname = strings.TrimSuffix(docname, ext)
+	}
+	if !strings.HasPrefix(docname, "/") {
+		dir := path.Dir(strings.TrimPrefix(strings.TrimPrefix(filename, "/"), "source"))
+		docname = path.Join("/", dir, docname)
+	}
+	return path.Clean(docname)
+}
+
+// Suggest returns the page most likely meant by a docname that doesn't exist: the only page with the same
+// name in another directory, or else the page whose docname is the fewest edits away, if that is close.
+func (pages PageMap) Suggest(docname string) (string, bool) {
+	docnames := make([]string, 0, len(pages))
+	for d := range pages {
+		docnames = append(docnames, d)
+	}
+	sort.Strings(docnames)
+
+	sameName := make([]string, 0)
+	for _, d := range docnames {
+		if path.Base(d) == path.Base(docname) {
+			sameName = append(sameName, d)
+		}
+	}
+	if len(sameName) == 1 {
+		return sameName[0], true
+	}
+
+	best, bestDistance := "", len(docname)/3+1
+	for _, d := range docnames {
+		if distance := editDistance(d, docname); distance < bestDistance {
+			best, bestDistance = d, distance
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Find returns the docname of the page served at the site path p, without slashes at either end: the
+// page itself, or the index page of a directory.
+func (pages PageMap) Find(p string) (string, bool) {
+	candidates := []string{"/" + p, "/" + p + "/index"}
+	if p == "" {
+		candidates = []string{"/index"}
+	}
+	for _, docname := range candidates {
+		if _, ok := pages[docname]; ok {
+			return docname, true
+		}
+	}
+	return "", false
+}
+
+// LabelIn returns the label defined in filename that Snooty renders with the html id anchor, which is
+// either the label itself or std-label- followed by the label.
+func (r RefTargetMap) LabelIn(filename string, anchor string) (string, bool) {
+	anchor = strings.TrimPrefix(anchor, "std-label-")
+	for target, files := range r {
+		if target.Name == anchor && containsName(files, filename) {
+			return target.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,111 @@
+package collectors
+
+import (
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPages = PageMap{
+	"/index":                     "/source/index.txt",
+	"/install":                   "/source/install.txt",
+	"/fundamentals/crud":         "/source/fundamentals/crud.txt",
+	"/fundamentals/crud/write":   "/source/fundamentals/crud/write.rst",
+	"/fundamentals/index":        "/source/fundamentals/index.txt",
+	"/reference/method/find-one": "/source/reference/method/find-one.txt",
+}
+
+func TestDocName(t *testing.T) {
+	cases := []struct {
+		filename string
+		docname  string
+		ok       bool
+	}{
+		{"/source/index.txt", "/index", true},
+		{"/source/fundamentals/crud/write.rst", "/fundamentals/crud/write", true},
+		{"/source/includes/steps.rst", "", false},
+		{"/source/includes/steps.yaml", "", false},
+		{"/source/images/diagram.png", "", false},
+		{"/snooty.toml", "", false},
+	}
+	for _, c := range cases {
+		docname, ok := DocName(c.filename)
+		assert.Equal(t, c.ok, ok, c.filename)
+		assert.Equal(t, c.docname, docname, c.filename)
+	}
+}
+
+func TestPageMapResolve(t *testing.T) {
+	cases := []struct {
+		target   string
+		filename string
+		docname  string
+		ok       bool
+	}{
+		{"/install", "/source/index.txt", "/install", true},
+		{"/install.txt", "/source/index.txt", "/install", true},
+		{"/fundamentals/crud/", "/source/index.txt", "/fundamentals/crud", true},
+		{"/fundamentals", "/source/index.txt", "/fundamentals/index", true},
+		{"/", "/source/install.txt", "/index", true},
+		{"write", "/source/fundamentals/crud.txt", "", false},
+		{"crud/write", "/source/fundamentals/crud.txt", "/fundamentals/crud/write", true},
+		{"../install", "/source/fundamentals/crud.txt", "/install", true},
+		{"/fundamentals/\n   crud/write", "/source/index.txt", "/fundamentals/crud/write", true},
+		{"/instal", "/source/index.txt", "/instal", false},
+		{"write", "/source/fundamentals/index.txt", "/fundamentals/write", false},
+	}
+	for _, c := range cases {
+		docname, ok := testPages.Resolve(c.target, c.filename)
+		assert.Equal(t, c.ok, ok, "Resolve(%q, %q)", c.target, c.filename)
+		if c.docname != "" {
+			assert.Equal(t, c.docname, docname, "Resolve(%q, %q)", c.target, c.filename)
+		}
+	}
+}
+
+func TestPageMapGlob(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		filename string
+		expected []string
+	}{
+		{"/fundamentals/*", "/source/index.txt", []string{"/fundamentals/crud", "/fundamentals/index"}},
+		{"*", "/source/fundamentals/index.txt", []string{"/fundamentals/crud"}},
+		{"/fundamentals/crud/*.rst", "/source/index.txt", []string{"/fundamentals/crud/write"}},
+		{"/*", "/source/index.txt", []string{"/install"}},
+		{"/reference/*", "/source/index.txt", []string{}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, testPages.Glob(c.pattern, c.filename), "Glob(%q, %q)", c.pattern, c.filename)
+	}
+}
+
+func TestPageMapSuggest(t *testing.T) {
+	cases := []struct {
+		docname    string
+		suggestion string
+	}{
+		{"/instal", "/install"},
+		{"/fundamentals/write", "/fundamentals/crud/write"},
+		{"/method/find-one", "/reference/method/find-one"},
+		{"/aggregation/pipeline", ""},
+	}
+	for _, c := range cases {
+		suggestion, ok := testPages.Suggest(c.docname)
+		assert.Equal(t, c.suggestion != "", ok, c.docname)
+		assert.Equal(t, c.suggestion, suggestion, c.docname)
+	}
+}
+
+func TestRefTargetMapLabelIn(t *testing.T) {
+	refs := RefTargetMap{
+		rst.RefTarget{Name: "install-linux"}: {"/source/install.txt"},
+		rst.RefTarget{Name: "crud-write"}:    {"/source/fundamentals/crud/write.rst"},
+	}
+	label, ok := refs.LabelIn("/source/install.txt", "std-label-install-linux")
+	assert.True(t, ok)
+	assert.Equal(t, "install-linux", label)
+	_, ok = refs.LabelIn("/source/install.txt", "crud-write")
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+package docset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/sources"
+)
+
+const (
+	// KindUnknownDirective marks directives that rstspec.toml doesn't define.
+	KindUnknownDirective = "unknown-directive"
+	// KindDeprecatedDirective marks directives that rstspec.toml marks as deprecated.
+	KindDeprecatedDirective = "deprecated-directive"
+	// KindMissingArgument marks directives without the argument they require.
+	KindMissingArgument = "missing-argument"
+	// KindBadArgument marks directive arguments that aren't of the directive's argument type.
+	KindBadArgument = "bad-argument"
+	// KindUnknownOption marks options that a directive doesn't take.
+	KindUnknownOption = "unknown-option"
+	// KindBadOption marks option values that aren't of the option's type.
+	KindBadOption = "bad-option"
+)
+
+// Problem is something wrong with a directive used in Filename.
+type Problem struct {
+	Kind     string
+	Filename string
+	Message  string
+}
+
+// CheckDirectives checks every directive in uses against its spec in rstspec.toml: it must be defined
+// and not deprecated, have an argument if it requires one, and take each of its options, with a value of
+// the option's type. Directives rstspec.toml only defines as rstobjects are checked for their names alone.
+// Nothing is checked if spec has no directives, as when rstspec.toml couldn't be loaded.
+func CheckDirectives(spec *sources.RstSpec, uses []collectors.DirectiveUse) []Problem {
+	problems := make([]Problem, 0)
+	if spec == nil || len(spec.Directives) == 0 {
+		return problems
+	}
+	for _, use := range uses {
+		d := use.Directive
+		used := strings.TrimSpace(fmt.Sprintf(".. %s:: %s", d.Name, d.Target))
+		problem := func(kind, format string, a ...interface{}) {
+			problems = append(problems, Problem{Kind: kind, Filename: use.Filename, Message: used + " " + fmt.Sprintf(format, a...)})
+		}
+
+		directive, ok := spec.Directive(d.Name)
+		if !ok {
+			if !spec.RstObjects[d.Name[strings.LastIndex(d.Name, ":")+1:]] {
+				problem(KindUnknownDirective, "is not a directive in rstspec.toml")
+			}
+			continue
+		}
+		if directive.Deprecated {
+			problem(KindDeprecatedDirective, "is deprecated")
+		}
+		if strings.TrimSpace(d.Target) == "" {
+			if directive.ArgumentType.Required() {
+				problem(KindMissingArgument, "needs an argument of type %s", strings.Join(directive.ArgumentType, " or "))
+			}
+		} else if err := directive.ArgumentType.Check(d.Target, spec.Enums); err != nil {
+			problem(KindBadArgument, "has an argument that %v", err)
+		}
+
+		options := make([]string, 0, len(d.Options))
+		for option := range d.Options {
+			options = append(options, option)
+		}
+		sort.Strings(options)
+		for _, option := range options {
+			t, ok := directive.Options[option]
+			if !ok {
+				problem(KindUnknownOption, "doesn't take the :%s: option", option)
+			} else if err := t.Check(d.Options[option], spec.Enums); err != nil {
+				value := strings.TrimSpace(d.Options[option])
+				if value != "" {
+					value = " " + value
+				}
+				problem(KindBadOption, "has :%s:%s, which %v", option, value, err)
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,69 @@
+package docset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+const rstSpec = `
+[enum]
+user_level = ["beginner", "intermediate", "advanced"]
+
+[directive.note]
+argument_type = "string?"
+content_type = "block"
+options.level = "user_level"
+
+[directive.code-block]
+argument_type = "string"
+options.linenos = "flag"
+options.emphasize-lines = "linenos"
+options.copyable = "boolean"
+
+[directive.div]
+deprecated = true
+argument_type = "string"
+
+[directive.image]
+argument_type = "uri"
+options.width = "length"
+
+[directive.level]
+argument_type = "user_level"
+
+[directive."mongodb:method"]
+argument_type = "string"
+
+[rstobject."mongodb:dbcommand"]
+[rstobject."mongodb:authrole"]
+`
+
+func TestCheckDirectives(t *testing.T) {
+	files := collectors.GatherFiles("testdata/directives")
+	problems := make([]string, 0)
+	for _, p := range CheckDirectives(sources.NewRoleMap([]byte(rstSpec)), collectors.GatherDirectiveUses(files)) {
+		problems = append(problems, fmt.Sprintf("%s %s: %s", p.Kind, p.Filename, p.Message))
+	}
+
+	assert.Equal(t, []string{
+		"bad-option /source/index.txt: .. note:: Experts has :level: expert, which must be one of beginner, intermediate, advanced",
+		"missing-argument /source/index.txt: .. code-block:: needs an argument of type string",
+		"unknown-option /source/index.txt: .. code-block:: doesn't take the :caption: option",
+		"bad-option /source/index.txt: .. code-block:: has :copyable:, which must be true or false",
+		"bad-option /source/index.txt: .. code-block:: has :linenos: yes, which takes no value",
+		"deprecated-directive /source/index.txt: .. div:: legacy is deprecated",
+		"missing-argument /source/index.txt: .. image:: needs an argument of type uri",
+		"bad-option /source/index.txt: .. image:: has :width: wide, which must be a length, such as 100px or 50%",
+		"bad-argument /source/index.txt: .. level:: expert has an argument that must be one of beginner, intermediate, advanced",
+		"unknown-directive /source/index.txt: .. sidebar:: Nope is not a directive in rstspec.toml",
+	}, problems)
+}
+
+func TestCheckDirectivesWithoutSpec(t *testing.T) {
+	files := collectors.GatherFiles("testdata/directives")
+	assert.Empty(t, CheckDirectives(sources.NewRoleMap(nil), collectors.GatherDirectiveUses(files)))
+}
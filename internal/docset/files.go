@@ -0,0 +1,204 @@
+package docset
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/spf13/afero"
+)
+
+const (
+	// KindFileNotFound marks include, literalinclude, image, figure and input directives whose file
+	// doesn't exist.
+	KindFileNotFound = "file-not-found"
+	// KindMarkerNotFound marks literalincludes whose :start-after: or :end-before: text isn't in the file.
+	KindMarkerNotFound = "marker-not-found"
+	// KindBadLines marks literalincludes whose :lines: aren't ranges of lines in the file.
+	KindBadLines = "bad-lines"
+)
+
+// FileDirectives are the directives whose argument is a file of the docset.
+var FileDirectives = []string{"include", "literalinclude", "image", "figure", "input"}
+
+// generatedKinds are the directories under includes/ whose files Snooty generates from YAML, such as
+// includes/steps/install.rst from includes/steps-install.yaml.
+var generatedKinds = []string{"steps", "extracts", "release"}
+
+var constantRegex = regexp.MustCompile(`\{\+([^+{}]+)\+\}`)
+
+// SubstituteConstants replaces each {+name+} in s with the value of the constant. If a constant isn't
+// defined, its name is returned along with false.
+func SubstituteConstants(s string, constants map[string]string) (string, string, bool) {
+	undefined := ""
+	substituted := constantRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := constantRegex.FindStringSubmatch(match)[1]
+		value, ok := constants[name]
+		if !ok && undefined == "" {
+			undefined = name
+		}
+		return value
+	})
+	return substituted, undefined, undefined == ""
+}
+
+// fileReader reads the files of the docset at basepath, once each.
+type fileReader struct {
+	basepath string
+	contents map[string]*string
+}
+
+// read returns the contents of filename, or false if it isn't a file.
+func (r *fileReader) read(filename string) (string, bool) {
+	if content, ok := r.contents[filename]; ok {
+		return *content, content != nil
+	}
+	data, err := collectors.FSUtil.ReadFile(filepath.Join(r.basepath, filepath.FromSlash(filename)))
+	if err != nil {
+		r.contents[filename] = nil
+		return "", false
+	}
+	content := string(data)
+	r.contents[filename] = &content
+	return content, true
+}
+
+// generated reports whether Snooty builds filename from a YAML file of the docset: steps from
+// includes/steps-<name>.yaml, and extracts and release notes from the entry with ref: <name> in any
+// includes/<kind>-*.yaml.
+func (r *fileReader) generated(filename string) bool {
+	dir := path.Dir(filename)
+	kind, includes := path.Base(dir), path.Dir(dir)
+	if path.Base(includes) != "includes" || !contains(generatedKinds, kind) || path.Ext(filename) != ".rst" {
+		return false
+	}
+	name := strings.TrimSuffix(path.Base(filename), ".rst")
+	if kind == "steps" {
+		_, ok := r.read(path.Join(includes, "steps-"+name+".yaml"))
+		return ok
+	}
+	matches, err := afero.Glob(collectors.FS, filepath.Join(r.basepath, filepath.FromSlash(includes), kind+"-*.yaml"))
+	if err != nil {
+		return false
+	}
+	ref := regexp.MustCompile(`(?m)^ref:\s*` + regexp.QuoteMeta(name) + `\s*$`)
+	for _, match := range matches {
+		rel, err := filepath.Rel(r.basepath, match)
+		if err != nil {
+			continue
+		}
+		if content, ok := r.read("/" + filepath.ToSlash(rel)); ok && ref.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFiles checks that the file of every use of FileDirectives exists in the docset at basepath,
+// resolving its path the way Snooty does: constants are substituted, an absolute path is relative to
+// source/ and any other is relative to the file using it. The :start-after: and :end-before: markers of
+// a literalinclude must be in its file, and its :lines: must be lines of what is left.
+func CheckFiles(basepath string, uses []collectors.DirectiveUse, constants map[string]string) []Problem {
+	problems := make([]Problem, 0)
+	r := &fileReader{basepath: basepath, contents: make(map[string]*string)}
+	for _, use := range uses {
+		if !contains(FileDirectives, use.Directive.Name) {
+			continue
+		}
+		d := use.Directive
+		problem := func(kind, format string, a ...interface{}) {
+			message := fmt.Sprintf(".. %s:: %s ", d.Name, d.Target) + fmt.Sprintf(format, a...)
+			problems = append(problems, Problem{Kind: kind, Filename: use.Filename, Message: message})
+		}
+
+		target, undefined, ok := SubstituteConstants(d.Target, constants)
+		if !ok {
+			problem(KindFileNotFound, "uses the undefined constant %s", undefined)
+			continue
+		}
+		if target == "" || strings.Contains(target, "://") {
+			// a missing argument is reported with the other directive problems
+			continue
+		}
+		filename := IncludeFilename(target, use.Filename)
+		content, ok := r.read(filename)
+		if !ok {
+			if d.Name != "include" || !r.generated(filename) {
+				problem(KindFileNotFound, "refers to %s, which doesn't exist", filename)
+			}
+			continue
+		}
+		if d.Name != "literalinclude" {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+		if marker, ok := d.Options["start-after"]; ok {
+			if i := lineContaining(lines, marker); i >= 0 {
+				lines = lines[i+1:]
+			} else {
+				problem(KindMarkerNotFound, "starts after %q, which isn't in %s", marker, filename)
+				continue
+			}
+		}
+		if marker, ok := d.Options["end-before"]; ok {
+			if i := lineContaining(lines, marker); i >= 0 {
+				lines = lines[:i]
+			} else {
+				problem(KindMarkerNotFound, "ends before %q, which isn't in %s after the start", marker, filename)
+				continue
+			}
+		}
+		if spec, ok := d.Options["lines"]; ok {
+			if err := checkLines(spec, len(lines)); err != nil {
+				problem(KindBadLines, "has :lines: %s, but %v", spec, err)
+			}
+		}
+	}
+	return problems
+}
+
+func lineContaining(lines []string, marker string) int {
+	for i, line := range lines {
+		if strings.Contains(line, marker) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkLines checks a :lines: option, such as 1-3,5,8-, against the number of lines it selects from.
+func checkLines(spec string, count int) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		first, last := 1, count
+		var err error
+		if from != "" {
+			if first, err = strconv.Atoi(strings.TrimSpace(from)); err != nil || first < 1 {
+				return fmt.Errorf("%q isn't a line number or range", part)
+			}
+		}
+		if !isRange {
+			last = first
+		} else if to != "" {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || last < 1 {
+				return fmt.Errorf("%q isn't a line number or range", part)
+			}
+		}
+		if isRange && from == "" && to == "" {
+			return fmt.Errorf("%q isn't a line number or range", part)
+		}
+		if first > count || last > count {
+			return fmt.Errorf("there are only %d lines to include", count)
+		}
+		if first > last {
+			return fmt.Errorf("%q ends before it starts", part)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package docset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFiles(t *testing.T) {
+	files := collectors.GatherFiles("testdata/files")
+	uses := collectors.GatherDirectiveUses(files, FileDirectives...)
+	problems := make([]string, 0)
+	for _, p := range CheckFiles("testdata/files", uses, map[string]string{"version": "6.0"}) {
+		problems = append(problems, fmt.Sprintf("%s %s: %s", p.Kind, p.Filename, p.Message))
+	}
+
+	assert.ElementsMatch(t, []string{
+		"file-not-found /source/index.txt: .. include:: /includes/missing.rst refers to /source/includes/missing.rst, which doesn't exist",
+		"file-not-found /source/index.txt: .. include:: /includes/steps/upgrade.rst refers to /source/includes/steps/upgrade.rst, which doesn't exist",
+		"file-not-found /source/index.txt: .. figure:: images/missing.png refers to /source/images/missing.png, which doesn't exist",
+		"file-not-found /source/index.txt: .. input:: /includes/code/{+driver+}.py uses the undefined constant driver",
+		`marker-not-found /source/index.txt: .. literalinclude:: /includes/code/connect.py starts after "start disconnect", which isn't in /source/includes/code/connect.py`,
+		`bad-lines /source/tutorials/connect.txt: .. literalinclude:: ../includes/code/connect.py has :lines: 1-2,40-, but there are only 7 lines to include`,
+		`marker-not-found /source/tutorials/connect.txt: .. literalinclude:: ../includes/code/connect.py ends before "start connect", which isn't in /source/includes/code/connect.py after the start`,
+		`bad-lines /source/tutorials/connect.txt: .. literalinclude:: ../includes/code/connect.py has :lines: 3-1, but "3-1" ends before it starts`,
+	}, problems)
+}
+
+func TestSubstituteConstants(t *testing.T) {
+	cases := []struct {
+		input     string
+		expected  string
+		undefined string
+	}{
+		{"/includes/intro.rst", "/includes/intro.rst", ""},
+		{"/includes/{+version+}/note-{+driver+}.rst", "/includes/6.0/note-go.rst", ""},
+		{"/includes/{+api+}.rst", "/includes/.rst", "api"},
+	}
+
+	for _, test := range cases {
+		got, undefined, ok := SubstituteConstants(test.input, map[string]string{"version": "6.0", "driver": "go"})
+		assert.Equal(t, test.expected, got)
+		assert.Equal(t, test.undefined, undefined)
+		assert.Equal(t, test.undefined == "", ok)
+	}
+}
+
+func TestCheckLines(t *testing.T) {
+	cases := []struct {
+		spec  string
+		valid bool
+	}{
+		{"1", true},
+		{"1-3,5", true},
+		{"4-", true},
+		{"-2", true},
+		{"1-10", true},
+		{"11", false},
+		{"3-2", false},
+		{"a-b", false},
+		{"-", false},
+		{"0", false},
+	}
+
+	for _, test := range cases {
+		err := checkLines(test.spec, 10)
+		assert.Equal(t, test.valid, err == nil, "checkLines(%q, 10) returned %v", test.spec, err)
+	}
+}
@@ -0,0 +1,56 @@
+package docset
+
+import (
+	"sort"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+)
+
+// KindDuplicateLabel marks labels that are defined more than once.
+const KindDuplicateLabel = "duplicate-label"
+
+// Duplicate is a label defined in more than one place. Locations are the files defining it, or
+// "shared:" and the path of shared content.
+type Duplicate struct {
+	Label     string
+	Locations []string
+}
+
+// DuplicateLabels finds the labels that are defined more than once, comparing labels the way docutils
+// does, so that Install and install are the same label. Each file counts once, including include files
+// used by several pages.
+func DuplicateLabels(labels collectors.RefTargetMap) []Duplicate {
+	locations := make(map[string][]string)
+	spelled := make(map[string]string)
+	for target, files := range labels {
+		label := NormalizeLabel(target.Name)
+		if _, ok := spelled[label]; !ok || target.Name < spelled[label] {
+			spelled[label] = target.Name
+		}
+		for _, f := range files {
+			if !contains(locations[label], f) {
+				locations[label] = append(locations[label], f)
+			}
+		}
+	}
+
+	duplicates := make([]Duplicate, 0)
+	for label, files := range locations {
+		if len(files) < 2 {
+			continue
+		}
+		sort.Strings(files)
+		duplicates = append(duplicates, Duplicate{Label: spelled[label], Locations: files})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Label < duplicates[j].Label })
+	return duplicates
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
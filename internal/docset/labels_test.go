@@ -0,0 +1,30 @@
+package docset
+
+import (
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateLabels(t *testing.T) {
+	labels := collectors.GatherLocalRefs(collectors.GatherFiles("testdata/duplicates"))
+	labels.Add(rst.RefTarget{Name: "upgrade"}, "shared:dbx/upgrade.rst")
+	labels.Add(rst.RefTarget{Name: "intro"}, "/source/includes/intro.rst")
+
+	assert.Equal(t, []Duplicate{{
+		Label:     "Getting Started",
+		Locations: []string{"/source/index.txt", "/source/tutorial/install.txt"},
+	}, {
+		Label:     "install",
+		Locations: []string{"/source/index.txt", "/source/tutorial/install.txt"},
+	}, {
+		Label:     "upgrade",
+		Locations: []string{"/source/tutorial/upgrade.txt", "shared:dbx/upgrade.rst"},
+	}}, DuplicateLabels(labels))
+}
+
+func TestDuplicateLabelsNone(t *testing.T) {
+	assert.Empty(t, DuplicateLabels(collectors.GatherLocalRefs(collectors.GatherFiles("testdata/guide"))))
+}
@@ -0,0 +1,102 @@
+package docset
+
+import (
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/parsers/intersphinx"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/MongoCaleb/checker/internal/sources"
+)
+
+// KindObjectNotFound marks roles such as :method: or :setting: whose target is an object that no
+// directive of the docset defines, and that isn't in its intersphinx inventories.
+const KindObjectNotFound = "object-not-found"
+
+// Objects indexes the objects that rstspec.toml's rstobjects describe, such as MongoDB methods and
+// settings, by their domain-qualified type, such as mongodb:method. Objects are defined by directives,
+// such as .. method:: db.collection.find(), and by the entries of intersphinx inventories.
+type Objects struct {
+	spec  *sources.RstSpec
+	names map[string]map[string]bool
+}
+
+// NewObjects indexes the objects defined by the directives in uses and in inventory.
+func NewObjects(spec *sources.RstSpec, uses []collectors.DirectiveUse, inventory intersphinx.SphinxMap) *Objects {
+	o := &Objects{spec: spec, names: make(map[string]map[string]bool)}
+	add := func(typ, name string) {
+		if o.names[typ] == nil {
+			o.names[typ] = make(map[string]bool)
+		}
+		o.names[typ][ObjectName(name)] = true
+	}
+	for _, use := range uses {
+		for _, typ := range o.types(use.Directive.Name) {
+			add(typ, use.Directive.Target)
+		}
+	}
+	for typ, objects := range inventory {
+		for name := range objects {
+			add(typ, name)
+		}
+	}
+	return o
+}
+
+// ObjectName returns the name an object is known by, without the arguments or empty parentheses of a
+// callable, so that db.collection.find(), db.collection.find(query) and db.collection.find are the same.
+func ObjectName(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.Index(name, "("); i > 0 && strings.HasSuffix(name, ")") {
+		name = name[:i]
+	}
+	return name
+}
+
+// types returns the domain-qualified types of objects that the role or directive name refers to: the
+// name itself if it has a domain, or else every rstobject of that name.
+func (o *Objects) types(name string) []string {
+	if o.spec == nil {
+		return nil
+	}
+	types := o.spec.RstObjectTypes[name[strings.LastIndex(name, ":")+1:]]
+	if !strings.Contains(name, ":") {
+		return types
+	}
+	if contains(types, name) {
+		return []string{name}
+	}
+	return nil
+}
+
+// RoleNames returns the names of the roles whose targets are objects, both with and without their
+// domain. The roles Resolver resolves are left out.
+func (o *Objects) RoleNames() []string {
+	names := make([]string, 0)
+	if o.spec == nil {
+		return names
+	}
+	for name, types := range o.spec.RstObjectTypes {
+		for _, role := range append([]string{name}, types...) {
+			if !contains(RefRoles, role) && !contains(names, role) {
+				names = append(names, role)
+			}
+		}
+	}
+	return names
+}
+
+// Resolve reports whether the target of role is an object of the type the role refers to.
+func (o *Objects) Resolve(role rst.RstRole) bool {
+	target, ok := RefTarget(role)
+	if !ok {
+		return true
+	}
+	name := ObjectName(target)
+	for _, typ := range o.types(role.Name) {
+		if o.names[typ][name] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package docset
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/parsers/intersphinx"
+	"github.com/MongoCaleb/checker/internal/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+const objectsRstSpec = `
+[rstobject."mongodb:method"]
+type = "callable"
+
+[rstobject."mongodb:setting"]
+
+[rstobject."mongodb:authrole"]
+
+[rstobject."py:class"]
+`
+
+func TestResolveObjects(t *testing.T) {
+	files := collectors.GatherFiles("testdata/objects")
+	spec := sources.NewRoleMap([]byte(objectsRstSpec))
+	objects := NewObjects(spec, collectors.GatherDirectiveUses(files), intersphinx.SphinxMap{
+		"mongodb:method":    {"db.collection.insertOne()": {URL: "https://www.mongodb.com/docs/manual/reference/method/db.collection.insertOne/"}},
+		"mongodb:privilege": {"read": {URL: "https://www.mongodb.com/docs/manual/reference/privilege-actions/#read"}},
+	})
+
+	unresolved := make([]string, 0)
+	for _, use := range collectors.GatherRoleUses(files, objects.RoleNames()...) {
+		if !objects.Resolve(use.Role) {
+			unresolved = append(unresolved, fmt.Sprintf("%s :%s:`%s`", use.Filename, use.Role.Name, use.Role.Target))
+		}
+	}
+	sort.Strings(unresolved)
+
+	assert.Equal(t, []string{
+		"/source/index.txt :authrole:`read`",
+		"/source/index.txt :method:`net.port`",
+		"/source/index.txt :mongodb:method:`db.nope()`",
+		"/source/index.txt :setting:`net.bindIp`",
+	}, unresolved)
+}
+
+func TestObjectName(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{"db.collection.find()", "db.collection.find"},
+		{" sh.removeShardTag(shard, tag) ", "sh.removeShardTag"},
+		{"net.port", "net.port"},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, test.expected, ObjectName(test.name))
+	}
+}
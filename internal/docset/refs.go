@@ -0,0 +1,145 @@
+// Package docset resolves the cross-references between the pages of a docset, and to the docsets it
+// links to through intersphinx.
+package docset
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/parsers/intersphinx"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+)
+
+const (
+	// KindRefNotFound marks :ref: and Python roles whose target isn't a label of the docset, of a shared
+	// include, or in an intersphinx inventory.
+	KindRefNotFound = "ref-not-found"
+	// KindStaleInventory marks roles that an intersphinx inventory resolves to a url that is broken, or to
+	// a page without the anchor, because the inventory no longer matches the published site.
+	KindStaleInventory = "stale-inventory"
+)
+
+// RefRoles are the roles that Resolver resolves.
+var RefRoles = []string{"ref", "py:meth", "py:class"}
+
+// IsRefRole reports whether name is one of RefRoles.
+func IsRefRole(name string) bool {
+	return contains(RefRoles, name)
+}
+
+// refTypes are the domain:roles of the inventory objects each of RefRoles can refer to, as Sphinx
+// resolves them.
+var refTypes = map[string][]string{
+	"ref":      {"std:label"},
+	"py:meth":  {"py:method", "py:classmethod", "py:staticmethod"},
+	"py:class": {"py:class", "py:exception"},
+}
+
+// Resolver resolves ref roles against a docset's labels and its intersphinx inventories.
+type Resolver struct {
+	labels    map[string]bool
+	inventory intersphinx.SphinxMap
+}
+
+// NewResolver builds a Resolver for the labels defined in the docset and its shared includes, and the
+// joined intersphinx inventories.
+func NewResolver(labels collectors.RefTargetMap, inventory intersphinx.SphinxMap) *Resolver {
+	r := &Resolver{labels: make(map[string]bool, len(labels)), inventory: inventory}
+	for label := range labels {
+		r.labels[NormalizeLabel(label.Name)] = true
+	}
+	return r
+}
+
+// NormalizeLabel normalizes a label, or the target of a :ref:, the way docutils does: case is folded and
+// runs of whitespace become a single space.
+func NormalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// RefTarget returns what role refers to: its target without the ~ that shortens the link text. Targets
+// starting with ! are not links at all, and are returned along with false.
+func RefTarget(role rst.RstRole) (string, bool) {
+	target := strings.TrimSpace(role.Target)
+	if strings.HasPrefix(target, "!") {
+		return "", false
+	}
+	return strings.TrimPrefix(target, "~"), true
+}
+
+// inventoryName returns the name the target of role has in an inventory, where labels are normalized.
+func inventoryName(role rst.RstRole) (string, bool) {
+	target, ok := RefTarget(role)
+	if ok && role.Name == "ref" {
+		target = NormalizeLabel(target)
+	}
+	return target, ok
+}
+
+// Resolve reports whether the target of role exists: a :ref: target is a label of the docset, and any
+// target may be an object of the inventory that the role can refer to.
+func (r *Resolver) Resolve(role rst.RstRole) bool {
+	target, ok := RefTarget(role)
+	if !ok {
+		return true
+	}
+	if role.Name == "ref" && r.labels[NormalizeLabel(target)] {
+		return true
+	}
+	_, found := r.Lookup(role)
+	return found
+}
+
+// Lookup returns the inventory object the target of role refers to. :ref: targets are normalized labels;
+// Python objects are matched exactly, and one written as .name matches any object of that name.
+func (r *Resolver) Lookup(role rst.RstRole) (intersphinx.Object, bool) {
+	name, ok := inventoryName(role)
+	if !ok {
+		return intersphinx.Object{}, false
+	}
+	for _, typ := range refTypes[role.Name] {
+		if obj, ok := r.inventory.Lookup(typ, name); ok {
+			return obj, true
+		}
+		if !strings.HasPrefix(name, ".") {
+			continue
+		}
+		matches := make([]string, 0)
+		for n := range r.inventory[typ] {
+			if strings.HasSuffix(n, name) {
+				matches = append(matches, n)
+			}
+		}
+		if len(matches) > 0 {
+			sort.Strings(matches)
+			return r.inventory[typ][matches[0]], true
+		}
+	}
+	return intersphinx.Object{}, false
+}
+
+// External returns the inventory object that role links to: the one Lookup finds, unless the target of a
+// :ref: is a label of the docset, which Snooty links to instead.
+func (r *Resolver) External(role rst.RstRole) (intersphinx.Object, bool) {
+	target, ok := RefTarget(role)
+	if !ok || role.Name == "ref" && r.labels[NormalizeLabel(target)] {
+		return intersphinx.Object{}, false
+	}
+	return r.Lookup(role)
+}
+
+// Elsewhere returns an inventory object named by the target of role that the role can't refer to, such as
+// a class used with :py:meth:, along with its domain:role.
+func (r *Resolver) Elsewhere(role rst.RstRole) (string, intersphinx.Object, bool) {
+	name, ok := inventoryName(role)
+	if !ok {
+		return "", intersphinx.Object{}, false
+	}
+	for _, typ := range r.inventory.Roles(name) {
+		if !contains(refTypes[role.Name], typ) {
+			return typ, r.inventory[typ][name], true
+		}
+	}
+	return "", intersphinx.Object{}, false
+}
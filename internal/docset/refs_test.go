@@ -0,0 +1,165 @@
+package docset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/MongoCaleb/checker/internal/parsers/intersphinx"
+	"github.com/MongoCaleb/checker/internal/parsers/rst"
+	"github.com/stretchr/testify/assert"
+)
+
+// unresolvedRefs resolves every ref role in the docset at path and returns the ones that don't resolve.
+func unresolvedRefs(t *testing.T, path string, inventory intersphinx.SphinxMap) []string {
+	files := collectors.GatherFiles(path)
+	resolver := NewResolver(collectors.GatherLocalRefs(files), inventory)
+	unresolved := make([]string, 0)
+	for _, use := range collectors.GatherRoleUses(files, RefRoles...) {
+		if !resolver.Resolve(use.Role) {
+			unresolved = append(unresolved, fmt.Sprintf("%s :%s:`%s`", use.Filename, use.Role.Name, use.Role.Target))
+		}
+	}
+	sort.Strings(unresolved)
+	return unresolved
+}
+
+func TestResolveDocset(t *testing.T) {
+	inv, err := ioutil.ReadFile("testdata/guide/objects.inv")
+	assert.NoError(t, err)
+	inventory := intersphinx.Intersphinx(inv, "https://www.mongodb.com/docs/manual/")
+
+	assert.Equal(t, []string{
+		"/source/index.txt :py:class:`pymongo.MongoClients`",
+		"/source/index.txt :ref:`install-drivers`",
+		"/source/index.txt :ref:`no-such-label`",
+	}, unresolvedRefs(t, "testdata/guide", inventory))
+}
+
+func TestResolveDocsetWithoutInventory(t *testing.T) {
+	assert.Equal(t, []string{
+		"/source/index.txt :py:class:`pymongo.MongoClients`",
+		"/source/index.txt :py:meth:`~pymongo.collection.Collection.find`",
+		"/source/index.txt :ref:`aggregation-pipeline`",
+		"/source/index.txt :ref:`install-drivers`",
+		"/source/index.txt :ref:`no-such-label`",
+		"/source/tutorial/install.txt :py:class:`.MongoClient`",
+	}, unresolvedRefs(t, "testdata/guide", nil))
+}
+
+func TestResolveDocsetPages(t *testing.T) {
+	files := collectors.GatherFiles("testdata/guide")
+	pages := collectors.GatherPages(files)
+	unresolved := make([]string, 0)
+	for _, use := range collectors.GatherRoleUses(files, "doc") {
+		if docname, ok := pages.Resolve(use.Role.Target, use.Filename); !ok {
+			suggestion, _ := pages.Suggest(docname)
+			unresolved = append(unresolved, fmt.Sprintf("%s %s %s", use.Filename, docname, suggestion))
+		}
+	}
+	sort.Strings(unresolved)
+	assert.Equal(t, []string{
+		"/source/index.txt /tutorial/instal /tutorial/install",
+		"/source/tutorial/install.txt /tutorial/install-guide /tutorial/install",
+	}, unresolved)
+}
+
+func TestNormalizeLabel(t *testing.T) {
+	assert.Equal(t, "connection strings", NormalizeLabel(" Connection\n   Strings "))
+	assert.Equal(t, "install-driver", NormalizeLabel("Install-Driver"))
+}
+
+func TestRefTarget(t *testing.T) {
+	cases := []struct {
+		role   rst.RstRole
+		target string
+		ok     bool
+	}{
+		{rst.RstRole{Name: "ref", Target: "install-driver"}, "install-driver", true},
+		{rst.RstRole{Name: "py:meth", Target: "~pymongo.collection.Collection.find"}, "pymongo.collection.Collection.find", true},
+		{rst.RstRole{Name: "py:meth", Target: "!not_a_link"}, "", false},
+	}
+	for _, c := range cases {
+		target, ok := RefTarget(c.role)
+		assert.Equal(t, c.ok, ok, c.role.Target)
+		assert.Equal(t, c.target, target, c.role.Target)
+	}
+}
+
+func TestResolverLookup(t *testing.T) {
+	resolver := NewResolver(nil, intersphinx.SphinxMap{
+		"std:label": {
+			"getting started": {URL: "https://pymongo.readthedocs.io/en/stable/tutorial.html#getting-started"},
+		},
+		"py:method": {
+			"pymongo.collection.Collection.find": {URL: "https://pymongo.readthedocs.io/en/stable/api/pymongo/collection.html#pymongo.collection.Collection.find"},
+		},
+		"py:exception": {
+			"pymongo.errors.OperationFailure": {URL: "https://pymongo.readthedocs.io/en/stable/api/pymongo/errors.html#pymongo.errors.OperationFailure"},
+		},
+		"py:class": {
+			"pymongo.mongo_client.MongoClient": {URL: "https://pymongo.readthedocs.io/en/stable/api/pymongo/mongo_client.html#pymongo.mongo_client.MongoClient"},
+		},
+	})
+
+	cases := []struct {
+		role rst.RstRole
+		url  string
+	}{
+		{rst.RstRole{Name: "ref", Target: "Getting\n  Started"}, "https://pymongo.readthedocs.io/en/stable/tutorial.html#getting-started"},
+		{rst.RstRole{Name: "py:meth", Target: "~pymongo.collection.Collection.find"}, "https://pymongo.readthedocs.io/en/stable/api/pymongo/collection.html#pymongo.collection.Collection.find"},
+		{rst.RstRole{Name: "py:meth", Target: ".Collection.find"}, "https://pymongo.readthedocs.io/en/stable/api/pymongo/collection.html#pymongo.collection.Collection.find"},
+		{rst.RstRole{Name: "py:class", Target: "pymongo.errors.OperationFailure"}, "https://pymongo.readthedocs.io/en/stable/api/pymongo/errors.html#pymongo.errors.OperationFailure"},
+		{rst.RstRole{Name: "py:class", Target: ".MongoClient"}, "https://pymongo.readthedocs.io/en/stable/api/pymongo/mongo_client.html#pymongo.mongo_client.MongoClient"},
+		{rst.RstRole{Name: "py:meth", Target: "pymongo.mongo_client.MongoClient"}, ""},
+		{rst.RstRole{Name: "ref", Target: "pymongo.collection.Collection.find"}, ""},
+	}
+	for _, c := range cases {
+		obj, ok := resolver.Lookup(c.role)
+		assert.Equal(t, c.url != "", ok, c.role.Target)
+		assert.Equal(t, c.url, obj.URL, c.role.Target)
+	}
+
+	typ, obj, ok := resolver.Elsewhere(rst.RstRole{Name: "py:meth", Target: "pymongo.mongo_client.MongoClient"})
+	assert.True(t, ok)
+	assert.Equal(t, "py:class", typ)
+	assert.Equal(t, "https://pymongo.readthedocs.io/en/stable/api/pymongo/mongo_client.html#pymongo.mongo_client.MongoClient", obj.URL)
+
+	_, _, ok = resolver.Elsewhere(rst.RstRole{Name: "py:class", Target: "pymongo.mongo_client.MongoClient"})
+	assert.False(t, ok)
+}
+
+func TestResolverExternal(t *testing.T) {
+	labels := collectors.RefTargetMap{rst.RefTarget{Name: "install-driver"}: {"/source/index.txt"}}
+	resolver := NewResolver(labels, intersphinx.SphinxMap{
+		"std:label": {
+			"install-driver":  {URL: "https://www.mongodb.com/docs/drivers/#install-driver"},
+			"getting-started": {URL: "https://www.mongodb.com/docs/manual/tutorial/getting-started/#getting-started"},
+		},
+	})
+
+	cases := []struct {
+		target string
+		url    string
+	}{
+		{"install-driver", ""},
+		{"Getting-Started", "https://www.mongodb.com/docs/manual/tutorial/getting-started/#getting-started"},
+		{"no-such-label", ""},
+	}
+	for _, c := range cases {
+		obj, ok := resolver.External(rst.RstRole{Name: "ref", Target: c.target})
+		assert.Equal(t, c.url != "", ok, c.target)
+		assert.Equal(t, c.url, obj.URL, c.target)
+	}
+}
+
+func TestIsRefRole(t *testing.T) {
+	for _, name := range RefRoles {
+		assert.True(t, IsRefRole(name), name)
+	}
+	assert.False(t, IsRefRole("py:func"))
+	assert.False(t, IsRefRole("meth"))
+	assert.False(t, IsRefRole("doc"))
+}
@@ -0,0 +1,7 @@
+import pymongo
+# start connect
+client = pymongo.MongoClient()
+db = client.test
+print(db)
+# end connect
+client.close()
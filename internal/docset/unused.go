@@ -0,0 +1,215 @@
+package docset
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+)
+
+// UnusedLabel is a label no :ref: in the docset refers to, and the files defining it.
+type UnusedLabel struct {
+	Label     string
+	Locations []string
+}
+
+// Orphan is a page that can't be reached from the docset's index page.
+type Orphan struct {
+	DocName  string
+	Filename string
+}
+
+// Unused is what nothing in the docset refers to.
+type Unused struct {
+	Labels   []UnusedLabel
+	Orphans  []Orphan
+	Includes []string
+}
+
+// graph is the reference graph of a docset. Its nodes are files: pages, and the include files whose
+// toctrees and roles belong to the pages that include them.
+type graph struct {
+	links      map[string][]string
+	includedBy map[string][]string
+}
+
+func (g *graph) link(from, to string) {
+	if !contains(g.links[from], to) {
+		g.links[from] = append(g.links[from], to)
+	}
+}
+
+// pagesOf returns the pages that show file: the page itself, or every page that includes it, directly or
+// through other include files.
+func (g *graph) pagesOf(file string) []string {
+	pages := make([]string, 0)
+	seen := map[string]bool{file: true}
+	queue := []string{file}
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		if _, ok := collectors.DocName(f); ok {
+			pages = append(pages, f)
+			continue
+		}
+		for _, includer := range g.includedBy[f] {
+			if !seen[includer] {
+				seen[includer] = true
+				queue = append(queue, includer)
+			}
+		}
+	}
+	return pages
+}
+
+// reachable returns every file reachable from roots.
+func (g *graph) reachable(roots []string) map[string]bool {
+	seen := make(map[string]bool)
+	queue := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if !seen[root] {
+			seen[root] = true
+			queue = append(queue, root)
+		}
+	}
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		for _, to := range g.links[f] {
+			if !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	return seen
+}
+
+// IncludeFilename returns the file an include or literalinclude target used in filename refers to: an
+// absolute target is relative to source/, and any other is relative to the directory of filename.
+func IncludeFilename(target string, filename string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Join("/source", target)
+	}
+	return path.Join(path.Dir(filename), target)
+}
+
+// FindUnused builds the reference graph of the docset made of files, from its toctrees, :doc: and :ref:
+// roles, and include and literalinclude directives, and returns the labels nothing refers to, the pages
+// that can't be reached from /index, and the include files nothing includes. The entries of a :glob:
+// toctree reach every page they match.
+//
+// Shared includes aren't fetched, so the refs in them aren't considered: a label only shared content refers
+// to is reported as unused, and should be allowed.
+//
+// allow lists what is unused on purpose, as path.Match patterns of labels, of docnames such as /landing,
+// or of include files such as /includes/legacy/*. An allowed page is reachable, along with every page it
+// refers to.
+func FindUnused(files []string, allow []string) Unused {
+	pages := collectors.GatherPages(files)
+	labels := collectors.GatherLocalRefs(files)
+	g := &graph{links: make(map[string][]string), includedBy: make(map[string][]string)}
+
+	linkDoc := func(target, filename string) {
+		if docname, ok := pages.Resolve(target, filename); ok {
+			g.link(filename, pages[docname])
+		}
+	}
+	for _, use := range collectors.GatherDirectiveUses(files, "toctree", "include", "literalinclude") {
+		target := use.Directive.Target
+		if target == "" || strings.Contains(target, "{+") {
+			continue
+		}
+		switch use.Directive.Name {
+		case "toctree":
+			if _, glob := use.Directive.Options["glob"]; glob && strings.ContainsAny(target, "*?[") {
+				for _, docname := range pages.Glob(target, use.Filename) {
+					g.link(use.Filename, pages[docname])
+				}
+			} else if target != "self" && !strings.Contains(target, "://") {
+				linkDoc(target, use.Filename)
+			}
+		default:
+			included := IncludeFilename(target, use.Filename)
+			g.link(use.Filename, included)
+			if !contains(g.includedBy[included], use.Filename) {
+				g.includedBy[included] = append(g.includedBy[included], use.Filename)
+			}
+		}
+	}
+
+	defined := make(map[string][]string)
+	spelled := make(map[string]string)
+	for target, fs := range labels {
+		label := NormalizeLabel(target.Name)
+		if _, ok := spelled[label]; !ok || target.Name < spelled[label] {
+			spelled[label] = target.Name
+		}
+		for _, f := range fs {
+			if !contains(defined[label], f) {
+				defined[label] = append(defined[label], f)
+			}
+		}
+	}
+	referenced := make(map[string]bool)
+	for _, use := range collectors.GatherRoleUses(files, "doc", "ref") {
+		if use.Role.Name == "doc" {
+			linkDoc(use.Role.Target, use.Filename)
+			continue
+		}
+		target, ok := RefTarget(use.Role)
+		if !ok {
+			continue
+		}
+		label := NormalizeLabel(target)
+		referenced[label] = true
+		for _, f := range defined[label] {
+			for _, page := range g.pagesOf(f) {
+				g.link(use.Filename, page)
+			}
+		}
+	}
+
+	unused := Unused{Labels: make([]UnusedLabel, 0), Orphans: make([]Orphan, 0), Includes: make([]string, 0)}
+	for label, fs := range defined {
+		if referenced[label] || allowed(allow, spelled[label]) {
+			continue
+		}
+		sort.Strings(fs)
+		unused.Labels = append(unused.Labels, UnusedLabel{Label: spelled[label], Locations: fs})
+	}
+	sort.Slice(unused.Labels, func(i, j int) bool { return unused.Labels[i].Label < unused.Labels[j].Label })
+
+	roots := make([]string, 0)
+	for docname, filename := range pages {
+		if docname == "/index" || allowed(allow, docname) {
+			roots = append(roots, filename)
+		}
+	}
+	reached := g.reachable(roots)
+	for docname, filename := range pages {
+		if !reached[filename] {
+			unused.Orphans = append(unused.Orphans, Orphan{DocName: docname, Filename: filename})
+		}
+	}
+	sort.Slice(unused.Orphans, func(i, j int) bool { return unused.Orphans[i].DocName < unused.Orphans[j].DocName })
+
+	for _, filename := range collectors.GatherIncludeFiles(files) {
+		if len(g.includedBy[filename]) == 0 && !allowed(allow, strings.TrimPrefix(filename, "/source")) {
+			unused.Includes = append(unused.Includes, filename)
+		}
+	}
+	sort.Strings(unused.Includes)
+	return unused
+}
+
+// allowed reports whether name matches one of the patterns in allow.
+func allowed(allow []string, name string) bool {
+	for _, pattern := range allow {
+		if ok, err := path.Match(pattern, name); pattern == name || (err == nil && ok) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package docset
+
+import (
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/collectors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUnused(t *testing.T) {
+	cases := []struct {
+		allow    []string
+		expected Unused
+	}{{
+		allow: []string{"/landing/*", "intro", "/includes/legacy/*"},
+		expected: Unused{
+			Labels: []UnusedLabel{
+				{Label: "landing", Locations: []string{"/source/index.txt"}},
+				{Label: "legacy-label", Locations: []string{"/source/legacy.txt"}},
+			},
+			Orphans: []Orphan{
+				{DocName: "/legacy", Filename: "/source/legacy.txt"},
+				{DocName: "/old", Filename: "/source/old.txt"},
+			},
+			Includes: []string{"/source/includes/unused.rst"},
+		},
+	}, {
+		allow: nil,
+		expected: Unused{
+			Labels: []UnusedLabel{
+				{Label: "intro", Locations: []string{"/source/includes/intro.rst"}},
+				{Label: "landing", Locations: []string{"/source/index.txt"}},
+				{Label: "legacy-label", Locations: []string{"/source/legacy.txt"}},
+			},
+			Orphans: []Orphan{
+				{DocName: "/hidden", Filename: "/source/hidden.txt"},
+				{DocName: "/landing/cloud", Filename: "/source/landing/cloud.txt"},
+				{DocName: "/legacy", Filename: "/source/legacy.txt"},
+				{DocName: "/old", Filename: "/source/old.txt"},
+			},
+			Includes: []string{"/source/includes/legacy/old-note.rst", "/source/includes/unused.rst"},
+		},
+	}}
+
+	files := collectors.GatherFiles("testdata/unused")
+	for _, test := range cases {
+		got := FindUnused(files, test.allow)
+		assert.Equal(t, test.expected, got, "FindUnused with allow %v", test.allow)
+	}
+}
+
+func TestIncludeFilename(t *testing.T) {
+	cases := []struct {
+		target   string
+		filename string
+		expected string
+	}{
+		{"/includes/intro.rst", "/source/index.txt", "/source/includes/intro.rst"},
+		{"../includes/steps.rst", "/source/tutorials/connect.txt", "/source/includes/steps.rst"},
+		{"note.rst", "/source/includes/intro.rst", "/source/includes/note.rst"},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, test.expected, IncludeFilename(test.target, test.filename))
+	}
+}
@@ -0,0 +1,167 @@
+package fixer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// maxDiffCells bounds the table the lines that differ are diffed with, as the number of lines removed
+// times the number of lines added. Fixes only touch a few lines, so only a file rewritten wholesale comes
+// close.
+const maxDiffCells = 1 << 24
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+'). a and b are the indexes of the
+// line in the old and new file, or of the line that follows it in the file it isn't in.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// UnifiedDiff renders the change from a to b as a unified diff for name, with diffContext lines of context
+// around each change. Fixes may join or split lines, such as a link written across two lines, so the lines
+// are matched by their longest common subsequence. An error is returned if too much of the file changed
+// for that to be cheap.
+func UnifiedDiff(name string, a, b []byte) (string, error) {
+	ops, err := diffLines(splitLines(a), splitLines(b))
+	if err != nil {
+		return "", err
+	}
+
+	changed := make([]int, 0)
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(changed); {
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*diffContext {
+			j++
+		}
+		start := changed[i] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := changed[j] + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunk := ops[start:end]
+		aLen, bLen := 0, 0
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunk[0].a, aLen), hunkRange(hunk[0].b, bLen))
+		for _, op := range hunk {
+			writeLine(&sb, string(op.kind), op.line)
+		}
+		i = j + 1
+	}
+	return sb.String(), nil
+}
+
+// splitLines splits data into lines that keep their newlines, without the empty line after a final newline.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the lines of al and bl as kept, removed and added lines, with the removed lines of each
+// change ahead of the lines added in their place.
+func diffLines(al, bl []string) ([]diffOp, error) {
+	prefix := 0
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+	am, bm := al[prefix:len(al)-suffix], bl[prefix:len(bl)-suffix]
+	if len(am)*len(bm) > maxDiffCells {
+		return nil, fmt.Errorf("%d lines were replaced by %d, too many to diff", len(am), len(bm))
+	}
+
+	// common[i][j] is the length of the longest common subsequence of am[i:] and bm[j:]
+	common := make([][]int, len(am)+1)
+	for i := range common {
+		common[i] = make([]int, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(al)+len(bm))
+	for k := 0; k < prefix; k++ {
+		ops = append(ops, diffOp{' ', al[k], k, k})
+	}
+	removed, added := make([]diffOp, 0), make([]diffOp, 0)
+	flush := func() {
+		ops = append(append(ops, removed...), added...)
+		removed, added = removed[:0], added[:0]
+	}
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			flush()
+			ops = append(ops, diffOp{' ', am[i], prefix + i, prefix + j})
+			i++
+			j++
+		case j == len(bm) || (i < len(am) && common[i+1][j] >= common[i][j+1]):
+			removed = append(removed, diffOp{'-', am[i], prefix + i, prefix + j})
+			i++
+		default:
+			added = append(added, diffOp{'+', bm[j], prefix + i, prefix + j})
+			j++
+		}
+	}
+	flush()
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{' ', al[len(al)-suffix+k], len(al) - suffix + k, len(bl) - suffix + k})
+	}
+	return ops, nil
+}
+
+// hunkRange renders the range of a hunk that starts at the 0-based line start and spans n lines. An empty
+// range names the line before it, as diff and patch expect.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func writeLine(sb *strings.Builder, prefix, line string) {
+	sb.WriteString(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,121 @@
+package fixer
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/parsers/links"
+)
+
+// Fix replaces every occurrence of the url Old in a source file with New.
+type Fix struct {
+	Old    string
+	New    string
+	Reason string
+
+	// role and target are set for fixes from RoleFix
+	role   string
+	target string
+}
+
+// RoleFix builds a fix that replaces the url old with an RST role, such as :doc:`/tutorial`. Anonymous
+// links, `text <url>`__, keep their text, as in :doc:`text </tutorial>`. Links in directives, and named
+// links, which other text may refer to, are left alone.
+func RoleFix(old, role, target, reason string) Fix {
+	return Fix{Old: old, New: ":" + role + ":`" + target + "`", Reason: reason, role: role, target: target}
+}
+
+// allSchemes are the schemes of every link the parsers extract.
+var allSchemes = append(append([]string{}, links.HTTPSchemes...), links.OtherSchemes...)
+
+// isURLByte reports whether b can continue a url path, so that a fix for {+api+}/b does not touch
+// {+api+}/bc. Fixes for whole links use the link parser instead.
+func isURLByte(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("-@:%_+.~#?&/=", b) >= 0
+}
+
+// Apply rewrites input with fixes. Only whole urls, as the link parser reads them, are replaced and every
+// other byte is left untouched.
+func Apply(input []byte, fixes []Fix) []byte {
+	out := input
+	for _, fix := range fixes {
+		if fix.Old == "" || fix.Old == fix.New {
+			continue
+		}
+		old := []byte(fix.Old)
+		isLink := links.At(old, allSchemes) == fix.Old
+		var buf bytes.Buffer
+		rest := out
+		for {
+			i := bytes.Index(rest, old)
+			if i < 0 {
+				buf.Write(rest)
+				break
+			}
+			end := i + len(old)
+			if fix.role != "" && isLink && links.At(rest[i:], allSchemes) == fix.Old {
+				if start, stop, replacement, ok := roleReplacement(rest, i, end, fix); ok {
+					buf.Write(rest[:start])
+					buf.WriteString(replacement)
+					rest = rest[stop:]
+					continue
+				}
+				buf.Write(rest[:end])
+				rest = rest[end:]
+				continue
+			}
+			buf.Write(rest[:i])
+			if isLink && links.At(rest[i:], allSchemes) != fix.Old {
+				buf.Write(old)
+			} else if !isLink && end < len(rest) && isURLByte(rest[end]) {
+				buf.Write(old)
+			} else {
+				buf.WriteString(fix.New)
+			}
+			rest = rest[end:]
+		}
+		out = buf.Bytes()
+	}
+	return out
+}
+
+// roleReplacement works out what to replace with a role for the link at text[i:end]: the whole anonymous
+// link around it, or just the link if it is bare. It reports false if the link can't become a role.
+func roleReplacement(text []byte, i, end int, fix Fix) (int, int, string, bool) {
+	lineStart := bytes.LastIndexByte(text[:i], '\n') + 1
+	line := bytes.TrimSpace(text[lineStart:i])
+	if bytes.HasPrefix(line, []byte("..")) || bytes.HasPrefix(line, []byte(":")) {
+		return 0, 0, "", false
+	}
+	if i == 0 || text[i-1] != '<' {
+		if (i > 0 && text[i-1] == '`') || (end < len(text) && text[end] == '`') {
+			return 0, 0, "", false
+		}
+		return i, end, fix.New, true
+	}
+	const anonymous = ">`__"
+	open := bytes.LastIndexByte(text[:i], '`')
+	if open < 0 || !bytes.HasPrefix(text[end:], []byte(anonymous)) || bytes.Contains(text[open:i], []byte("\n\n")) {
+		return 0, 0, "", false
+	}
+	title := strings.TrimSpace(string(text[open+1 : i-1]))
+	if title == "" {
+		return open, end + len(anonymous), fix.New, true
+	}
+	return open, end + len(anonymous), ":" + fix.role + ":`" + title + " <" + fix.target + ">`", true
+}
+
+// ConstantFix builds a fix for a link written as {+name+}target, where prefix is the constant's value and
+// newURL is the replacement for the expanded link. The constant is kept, so the fix is only possible
+// when newURL still starts with prefix.
+func ConstantFix(name, prefix, target, newURL, reason string) (Fix, bool) {
+	if prefix == "" || !strings.HasPrefix(newURL, prefix) {
+		return Fix{}, false
+	}
+	constant := "{+" + name + "+}"
+	return Fix{Old: constant + target, New: constant + strings.TrimPrefix(newURL, prefix), Reason: reason}, true
+}
@@ -0,0 +1,188 @@
+package fixer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		input    string
+		fixes    []Fix
+		expected string
+	}{{
+		input:    "",
+		fixes:    []Fix{{Old: "https://a.com/b", New: "https://a.com/c"}},
+		expected: "",
+	}, {
+		input:    "See `the docs <https://docs.mongodb.com/manual/>`__.\r\n",
+		fixes:    []Fix{{Old: "https://docs.mongodb.com/manual/", New: "https://www.mongodb.com/docs/manual/"}},
+		expected: "See `the docs <https://www.mongodb.com/docs/manual/>`__.\r\n",
+	}, {
+		input:    "https://a.com/b and https://a.com/bc and https://a.com/b/ and https://a.com/b.c and (https://a.com/b).",
+		fixes:    []Fix{{Old: "https://a.com/b", New: "https://a.com/c"}},
+		expected: "https://a.com/c and https://a.com/bc and https://a.com/b/ and https://a.com/b.c and (https://a.com/c).",
+	}, {
+		input:    "http://example.org\n  http://example.org",
+		fixes:    []Fix{{Old: "http://example.org", New: "https://example.org"}},
+		expected: "https://example.org\n  https://example.org",
+	}, {
+		input:    "`Collection <{+api+}/classes/Collection.html>`__",
+		fixes:    []Fix{{Old: "{+api+}/classes/Collection.html", New: "{+api+}/classes/MongoCollection.html"}},
+		expected: "`Collection <{+api+}/classes/MongoCollection.html>`__",
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, string(Apply([]byte(c.input), c.fixes)), "Apply(%q)", c.input)
+	}
+}
+
+func TestRoleFix(t *testing.T) {
+	fixes := []Fix{
+		RoleFix("https://www.mongodb.com/docs/compass/install/", "doc", "/install", "links to this docset"),
+		RoleFix("https://www.mongodb.com/docs/compass/query/#std-label-filter", "ref", "filter", "links to this docset"),
+	}
+	cases := []struct {
+		input    string
+		expected string
+	}{{
+		input:    "See https://www.mongodb.com/docs/compass/install/.",
+		expected: "See :doc:`/install`.",
+	}, {
+		input:    "See `Install Compass <https://www.mongodb.com/docs/compass/install/>`__ and\n`filters\n<https://www.mongodb.com/docs/compass/query/#std-label-filter>`__.",
+		expected: "See :doc:`Install Compass </install>` and\n:ref:`filters <filter>`.",
+	}, {
+		input:    "`named links <https://www.mongodb.com/docs/compass/install/>`_ stay, as does ``https://www.mongodb.com/docs/compass/install/``",
+		expected: "`named links <https://www.mongodb.com/docs/compass/install/>`_ stay, as does ``https://www.mongodb.com/docs/compass/install/``",
+	}, {
+		input:    ".. image:: /images/a.png\n   :target: https://www.mongodb.com/docs/compass/install/",
+		expected: ".. image:: /images/a.png\n   :target: https://www.mongodb.com/docs/compass/install/",
+	}}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, string(Apply([]byte(c.input), fixes)), "Apply(%q)", c.input)
+	}
+}
+
+func TestConstantFix(t *testing.T) {
+	fix, ok := ConstantFix("api", "https://mongodb.github.io/node-mongodb-native/4.2", "/classes/Db.html", "https://mongodb.github.io/node-mongodb-native/4.2/classes/Db.html#stats", "permanent redirect")
+	assert.True(t, ok)
+	assert.Equal(t, Fix{Old: "{+api+}/classes/Db.html", New: "{+api+}/classes/Db.html#stats", Reason: "permanent redirect"}, fix)
+
+	_, ok = ConstantFix("api", "https://mongodb.github.io/node-mongodb-native/4.2", "/classes/Db.html", "https://www.mongodb.com/docs/drivers/node/", "permanent redirect")
+	assert.False(t, ok, "a fix that changes the constant's prefix cannot keep the constant")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	b := "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\nTWELVE\n"
+
+	expected := `--- a/source/index.txt
++++ b/source/index.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+ three
+ four
+ five
+@@ -9,4 +9,4 @@
+ nine
+ ten
+ eleven
+-twelve
++TWELVE
+`
+	diff, err := UnifiedDiff("source/index.txt", []byte(a), []byte(b))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, diff)
+	diff, err = UnifiedDiff("source/index.txt", []byte(a), []byte(a))
+	assert.NoError(t, err)
+	assert.Equal(t, "", diff)
+}
+
+func TestUnifiedDiffMergesNearbyChanges(t *testing.T) {
+	a := "a\nb\nc\nd\ne"
+	b := "A\nb\nc\nD\nE"
+
+	expected := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+-a
++A
+ b
+ c
+-d
+-e
+\ No newline at end of file
++D
++E
+\ No newline at end of file
+`
+	diff, err := UnifiedDiff("x.txt", []byte(a), []byte(b))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, diff)
+}
+
+func TestUnifiedDiffJoinedLines(t *testing.T) {
+	a := "Intro\n\nSee the `server\n<https://www.mongodb.com/docs/manual/>`__ docs.\n\none\ntwo\nthree\nfour\nfive\nsix\nlast\n"
+	b := "Intro\n\nSee the `server <https://www.mongodb.com/docs/manual/>`__ docs.\n\none\ntwo\nthree\nfour\nfive\nsix\nLAST\n"
+
+	expected := `--- a/x.txt
++++ b/x.txt
+@@ -1,7 +1,6 @@
+ Intro
+ 
+-See the ` + "`" + `server
+-<https://www.mongodb.com/docs/manual/>` + "`" + `__ docs.
++See the ` + "`" + `server <https://www.mongodb.com/docs/manual/>` + "`" + `__ docs.
+ 
+ one
+ two
+@@ -9,4 +8,4 @@
+ four
+ five
+ six
+-last
++LAST
+`
+	diff, err := UnifiedDiff("x.txt", []byte(a), []byte(b))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, diff)
+}
+
+func TestUnifiedDiffAddedLines(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected string
+	}{{
+		a:        "a\nb\n",
+		b:        "a\nb\nc\n",
+		expected: "--- a/x.txt\n+++ b/x.txt\n@@ -1,2 +1,3 @@\n a\n b\n+c\n",
+	}, {
+		a:        "",
+		b:        "a\n",
+		expected: "--- a/x.txt\n+++ b/x.txt\n@@ -0,0 +1,1 @@\n+a\n",
+	}, {
+		a:        "a\nb\nc\n",
+		b:        "a\nc\n",
+		expected: "--- a/x.txt\n+++ b/x.txt\n@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+	}}
+	for _, c := range cases {
+		diff, err := UnifiedDiff("x.txt", []byte(c.a), []byte(c.b))
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, diff, "%q -> %q", c.a, c.b)
+	}
+}
+
+func TestUnifiedDiffTooLarge(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	_, err := UnifiedDiff("x.txt", []byte(a.String()), []byte(b.String()))
+	assert.EqualError(t, err, "5000 lines were replaced by 5000, too many to diff")
+}
@@ -4,14 +4,31 @@ import (
 	"bytes"
 	"compress/zlib"
 	"io/ioutil"
+	"regexp"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type SphinxMap map[string]bool
+// SphinxMap maps each domain:role of an inventory, such as std:label or py:class, to its objects by name.
+type SphinxMap map[string]map[string]Object
 
-func Intersphinx(buff []byte, domain string) SphinxMap {
+// Object is an entry of an inventory. URL is where it is documented, and DisplayName is the text Sphinx
+// uses for links to it, which is the name itself unless the inventory gives one.
+type Object struct {
+	URL         string
+	DisplayName string
+}
+
+// lineRegex matches the lines of a version 2 inventory the way Sphinx does: the name may contain spaces,
+// and is followed by the domain:role, the priority, the uri and the display name.
+var lineRegex = regexp.MustCompile(`^(.+?)\s+(\S+)\s+(-?\d+)\s+?(\S*)\s+(.*)$`)
+
+// Intersphinx parses an objects.inv file. Uris are resolved against base, the url the inventory was
+// fetched from without objects.inv, with a $ at the end of a uri standing for the object's name, as
+// Sphinx writes them. nil is returned if the inventory can't be read.
+func Intersphinx(buff []byte, base string) SphinxMap {
 
 	markerLine := "# The remainder of this file is compressed using zlib.\n"
 	cut := bytes.Index(buff, []byte(markerLine)) + len(markerLine)
@@ -39,23 +56,61 @@ func Intersphinx(buff []byte, domain string) SphinxMap {
 		return nil
 	}
 
-	res := make(map[string]bool)
-
+	res := make(SphinxMap)
 	for _, line := range strings.Split(string(parsed), "\n") {
-		if len(line) == 0 {
+		m := lineRegex.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil || !strings.Contains(m[2], ":") {
 			continue
 		}
-		lineSplit := strings.Split(line, " ")
-		res[lineSplit[0]] = true
+		name, role, uri, dispname := m[1], m[2], m[4], m[5]
+		if strings.HasSuffix(uri, "$") {
+			uri = uri[:len(uri)-1] + name
+		}
+		if !strings.Contains(uri, "://") {
+			uri = base + uri
+		}
+		if dispname == "-" {
+			dispname = name
+		}
+		if res[role] == nil {
+			res[role] = make(map[string]Object)
+		}
+		res[role][name] = Object{URL: uri, DisplayName: dispname}
 	}
 	return res
 }
 
+// Lookup returns the object named name with the domain:role role.
+func (m SphinxMap) Lookup(role string, name string) (Object, bool) {
+	obj, ok := m[role][name]
+	return obj, ok
+}
+
+// Roles returns every domain:role that has an object named name, sorted.
+func (m SphinxMap) Roles(name string) []string {
+	roles := make([]string, 0)
+	for role, objects := range m {
+		if _, ok := objects[name]; ok {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// JoinSphinxes merges inventories. An object in more than one keeps the url of the first.
 func JoinSphinxes(input []SphinxMap) SphinxMap {
 	refMap := make(SphinxMap)
 	for _, m := range input {
-		for k, v := range m {
-			refMap[k] = v
+		for role, objects := range m {
+			if refMap[role] == nil {
+				refMap[role] = make(map[string]Object, len(objects))
+			}
+			for name, obj := range objects {
+				if _, ok := refMap[role][name]; !ok {
+					refMap[role][name] = obj
+				}
+			}
 		}
 	}
 	return refMap
@@ -71,7 +71,10 @@ func TestSomeContent(t *testing.T) {
 `)
 	zText := []byte(`whats-new std:doc -1 whats-new/ What's New
 compatibility std:doc -1 compatibility/ Compatibility
-fundamentals std:doc -1 fundamentals/ Fundamentals
+getting started std:label -1 fundamentals/#std-label-getting-started Getting Started
+db.collection.find() mongodb:method 1 reference/method/db.collection.find/#$ -
+pymongo.MongoClient py:class 1 https://pymongo.readthedocs.io/en/stable/api.html#$ -
+not an entry
 usage-examples std:doc -1 usage-examples/ Usage Examples`)
 
 	var b bytes.Buffer
@@ -84,38 +87,58 @@ usage-examples std:doc -1 usage-examples/ Usage Examples`)
 	resp := Intersphinx(append(header, b.Bytes()...), "https://test.com/")
 
 	expected := SphinxMap{
-		"whats-new":      true,
-		"compatibility":  true,
-		"fundamentals":   true,
-		"usage-examples": true,
+		"std:doc": {
+			"whats-new":      {URL: "https://test.com/whats-new/", DisplayName: "What's New"},
+			"compatibility":  {URL: "https://test.com/compatibility/", DisplayName: "Compatibility"},
+			"usage-examples": {URL: "https://test.com/usage-examples/", DisplayName: "Usage Examples"},
+		},
+		"std:label": {
+			"getting started": {URL: "https://test.com/fundamentals/#std-label-getting-started", DisplayName: "Getting Started"},
+		},
+		"mongodb:method": {
+			"db.collection.find()": {URL: "https://test.com/reference/method/db.collection.find/#db.collection.find()", DisplayName: "db.collection.find()"},
+		},
+		"py:class": {
+			"pymongo.MongoClient": {URL: "https://pymongo.readthedocs.io/en/stable/api.html#pymongo.MongoClient", DisplayName: "pymongo.MongoClient"},
+		},
 	}
 
 	assert.EqualValues(t, expected, resp, "Expected %v, got %v", expected, resp)
+
+	obj, ok := resp.Lookup("std:label", "getting started")
+	assert.True(t, ok)
+	assert.Equal(t, "Getting Started", obj.DisplayName)
+	_, ok = resp.Lookup("std:doc", "getting started")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"std:doc"}, resp.Roles("whats-new"))
 }
 
 func TestJoinSphinxes(t *testing.T) {
 	input := []SphinxMap{
 		{
-			"whats-new":      true,
-			"compatibility":  true,
-			"fundamentals":   true,
-			"usage-examples": true,
+			"std:doc": {
+				"whats-new":     {URL: "https://a.com/whats-new/", DisplayName: "What's New"},
+				"compatibility": {URL: "https://a.com/compatibility/", DisplayName: "Compatibility"},
+			},
 		}, {
-			"foowhats-new":      true,
-			"foocompatibility":  true,
-			"foofundamentals":   true,
-			"foousage-examples": true,
-		}}
+			"std:doc": {
+				"whats-new": {URL: "https://b.com/whats-new/", DisplayName: "What's New"},
+			},
+			"std:label": {
+				"fundamentals": {URL: "https://b.com/fundamentals/", DisplayName: "Fundamentals"},
+			},
+		},
+		nil,
+	}
 
 	expected := SphinxMap{
-		"whats-new":         true,
-		"compatibility":     true,
-		"fundamentals":      true,
-		"usage-examples":    true,
-		"foowhats-new":      true,
-		"foocompatibility":  true,
-		"foofundamentals":   true,
-		"foousage-examples": true,
+		"std:doc": {
+			"whats-new":     {URL: "https://a.com/whats-new/", DisplayName: "What's New"},
+			"compatibility": {URL: "https://a.com/compatibility/", DisplayName: "Compatibility"},
+		},
+		"std:label": {
+			"fundamentals": {URL: "https://b.com/fundamentals/", DisplayName: "Fundamentals"},
+		},
 	}
 
 	actual := JoinSphinxes(input)
@@ -0,0 +1,255 @@
+// Package links finds urls in prose and RST following RFC 3986, with the allowances people expect from
+// text: internationalized domain names and paths, sentence punctuation after a link, parentheses around
+// it, and RST's `text <url>`_ syntax, whose url may be wrapped over several lines.
+package links
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// HTTPSchemes are the schemes of links that are checked over HTTP.
+	HTTPSchemes = []string{"http", "https"}
+	// OtherSchemes are the other schemes checker knows how to validate.
+	OtherSchemes = []string{"mailto", "tel", "ftp", "ftps", "sftp", "git", "ssh", "git+ssh"}
+)
+
+// opaque schemes have no authority, so there is no host to validate.
+var opaque = map[string]bool{"mailto": true, "tel": true}
+
+// trailing is punctuation that ends a sentence or clause rather than a url.
+const trailing = ".,;:!?'*。、，．：；！？"
+
+// Extract returns every link with one of schemes in input, in order. Schemes are matched case-insensitively.
+func Extract(input []byte, schemes []string) []string {
+	text := string(input)
+	schemes = append([]string{}, schemes...)
+	// try longer schemes first so that git+ssh isn't cut short
+	sort.Slice(schemes, func(i, j int) bool { return len(schemes[i]) > len(schemes[j]) })
+
+	found := make([]string, 0)
+	for i := 0; i < len(text); {
+		if link, end, ok := linkAt(text, i, schemes); ok {
+			found = append(found, link)
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return found
+}
+
+// At returns the link with one of schemes that input starts with, or "" if it doesn't start with one.
+func At(input []byte, schemes []string) string {
+	schemes = append([]string{}, schemes...)
+	sort.Slice(schemes, func(i, j int) bool { return len(schemes[i]) > len(schemes[j]) })
+	if link, _, ok := linkAt(string(input), 0, schemes); ok {
+		return link
+	}
+	return ""
+}
+
+// HasHTTPLink reports whether s contains an http(s) link.
+func HasHTTPLink(s string) bool {
+	return len(Extract([]byte(s), HTTPSchemes)) > 0
+}
+
+// linkAt returns the link starting at text[i], if there is one, and where it ends.
+func linkAt(text string, i int, schemes []string) (string, int, bool) {
+	if !startsScheme(text, i) {
+		return "", 0, false
+	}
+	for _, scheme := range schemes {
+		body := i + len(scheme) + 1
+		if body > len(text) || !strings.EqualFold(text[i:body-1], scheme) || text[body-1] != ':' {
+			continue
+		}
+		if !opaque[scheme] && !strings.HasPrefix(text[body:], "//") {
+			continue
+		}
+
+		if i > 0 && text[i-1] == '<' {
+			if link, end, ok := embedded(text, i); ok {
+				return link, end, valid(link, scheme)
+			}
+		}
+		end := scan(text, body)
+		link := trim(text[i:end])
+		return link, i + len(link), valid(link, scheme)
+	}
+	return "", 0, false
+}
+
+// startsScheme reports whether a scheme could start at text[i]: it must begin with a letter that isn't
+// the continuation of a word or of another scheme.
+func startsScheme(text string, i int) bool {
+	if c := text[i]; !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && prev != '+' && prev != '-' && prev != '.'
+}
+
+// scan returns the end of the run of url characters starting at text[i]. An IPv6 host in brackets is
+// kept whole; other brackets end the url, as they do in markdown.
+func scan(text string, i int) int {
+	if strings.HasPrefix(text[i:], "//[") {
+		if close := strings.IndexByte(text[i:], ']'); close > 0 {
+			i += close + 1
+		}
+	}
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if terminates(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+func terminates(r rune) bool {
+	if r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+		return true
+	}
+	switch r {
+	case '<', '>', '"', '`', '{', '}', '|', '\\', '^', '[', ']':
+		return true
+	}
+	// typographic quotes, such as “ ” « »
+	return unicode.In(r, unicode.Pi, unicode.Pf)
+}
+
+// embedded reads the url of an RST `text <url>`_ reference starting at text[i]. RST drops whitespace
+// inside the angle brackets, so the url may be wrapped, and it is taken as written, without trimming
+// punctuation.
+func embedded(text string, i int) (string, int, bool) {
+	end := strings.Index(text[i:], ">`")
+	if end < 0 {
+		return "", 0, false
+	}
+	inner := text[i : i+end]
+	// a blank line ends the paragraph, and with it the reference
+	for _, line := range strings.Split(inner, "\n")[1:] {
+		if strings.TrimSpace(line) == "" {
+			return "", 0, false
+		}
+	}
+	var b strings.Builder
+	for _, r := range inner {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if terminates(r) {
+			return "", 0, false
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), i + end, true
+}
+
+// trim drops trailing punctuation from link, and closing parentheses that don't have a match in it.
+func trim(link string) string {
+	for link != "" {
+		r, size := utf8.DecodeLastRuneInString(link)
+		switch {
+		case strings.ContainsRune(trailing, r):
+		case r == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		default:
+			return link
+		}
+		link = link[:len(link)-size]
+	}
+	return link
+}
+
+// valid checks that link has something after its scheme and, unless the scheme is opaque, a host that
+// could exist: an IP address, localhost, or a domain name with a top level domain.
+func valid(link string, scheme string) bool {
+	if len(link) <= len(scheme)+1 {
+		return false
+	}
+	rest := link[len(scheme)+1:]
+	if opaque[scheme] {
+		return rest != ""
+	}
+	authority := strings.TrimPrefix(rest, "//")
+	if end := strings.IndexAny(authority, "/?#"); end >= 0 {
+		authority = authority[:end]
+	}
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		authority = authority[at+1:]
+	}
+
+	host := authority
+	if strings.HasPrefix(authority, "[") {
+		close := strings.Index(authority, "]")
+		if close < 0 || net.ParseIP(authority[1:close]) == nil {
+			return false
+		}
+		return validPort(authority[close+1:])
+	}
+	if colon := strings.LastIndex(authority, ":"); colon >= 0 {
+		if !validPort(authority[colon:]) {
+			return false
+		}
+		host = authority[:colon]
+	}
+	return validHost(host)
+}
+
+func validPort(port string) bool {
+	if port == "" {
+		return true
+	}
+	if port[0] != ':' || len(port) < 2 || len(port) > 6 {
+		return false
+	}
+	for _, c := range port[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validHost(host string) bool {
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r) && r != '-' && r != '_' && r != '%' {
+				return false
+			}
+		}
+	}
+	tld := labels[len(labels)-1]
+	if strings.HasPrefix(strings.ToLower(tld), "xn--") {
+		return len(tld) > 4
+	}
+	if utf8.RuneCountInString(tld) < 2 {
+		return false
+	}
+	for _, r := range tld {
+		if !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) {
+			return false
+		}
+	}
+	return true
+}
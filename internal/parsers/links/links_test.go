@@ -0,0 +1,137 @@
+package links
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{{
+		input:    "",
+		expected: []string{},
+	}, {
+		input:    "we can say http and www and https: without any links being found",
+		expected: []string{},
+	}, {
+		input:    "https://www.flibberptyquz.co",
+		expected: []string{"https://www.flibberptyquz.co"},
+	}, {
+		input:    "See https://www.mongodb.com/docs/manual/.",
+		expected: []string{"https://www.mongodb.com/docs/manual/"},
+	}, {
+		input:    "Use a driver (https://www.mongodb.com/docs/drivers/), or the shell, https://www.mongodb.com/try/download/shell; either works!",
+		expected: []string{"https://www.mongodb.com/docs/drivers/", "https://www.mongodb.com/try/download/shell"},
+	}, {
+		input:    "the article (see https://en.wikipedia.org/wiki/Fork_(software_development)).",
+		expected: []string{"https://en.wikipedia.org/wiki/Fork_(software_development)"},
+	}, {
+		input:    "this is a `url <https://www.github.com>`__ and `another <https://www.mongodb.com/docs/manual/reference/operator/query/>`_",
+		expected: []string{"https://www.github.com", "https://www.mongodb.com/docs/manual/reference/operator/query/"},
+	}, {
+		input:    "a `wrapped link <https://www.mongodb.com/docs/manual/\n   reference/method/>`__ in RST",
+		expected: []string{"https://www.mongodb.com/docs/manual/reference/method/"},
+	}, {
+		input:    "`explicit links are taken as written <https://example.com/trailing.>`__",
+		expected: []string{"https://example.com/trailing."},
+	}, {
+		input:    "[some markdown link](https://www.google.com) and [another](https://a.bad.url)",
+		expected: []string{"https://www.google.com", "https://a.bad.url"},
+	}, {
+		input:    "long TLDs work: https://www.mongodb.academy/course and https://docs.example.photography/x",
+		expected: []string{"https://www.mongodb.academy/course", "https://docs.example.photography/x"},
+	}, {
+		input:    "IDNs work: https://münchen.de/straße and https://xn--mnchen-3ya.de/ and https://例え.テスト/パス",
+		expected: []string{"https://münchen.de/straße", "https://xn--mnchen-3ya.de/", "https://例え.テスト/パス"},
+	}, {
+		input:    "percent-encoding: https://example.com/a%20b?q=%E2%9C%93#frag",
+		expected: []string{"https://example.com/a%20b?q=%E2%9C%93#frag"},
+	}, {
+		input:    "hosts: http://localhost:8080/x, http://10.0.0.1/, http://[::1]:27017/ and HTTPS://EXAMPLE.COM/",
+		expected: []string{"http://localhost:8080/x", "http://10.0.0.1/", "http://[::1]:27017/", "HTTPS://EXAMPLE.COM/"},
+	}, {
+		input:    "not links: https://nodot/ https://example.c/ https://{+api+}/x xhttps://example.com http://999.1.1.1/",
+		expected: []string{},
+	}, {
+		input:    "quoted “https://example.com/quoted” and «https://example.com/guillemets»",
+		expected: []string{"https://example.com/quoted", "https://example.com/guillemets"},
+	}, {
+		input:    "ends a Japanese sentence https://example.jp/ページ。",
+		expected: []string{"https://example.jp/ページ"},
+	}}
+	for _, c := range cases {
+		got := Extract([]byte(c.input), HTTPSchemes)
+		assert.Equal(t, c.expected, got, "Extract(%q)", c.input)
+	}
+}
+
+func TestExtractOtherSchemes(t *testing.T) {
+	input := "Email `support <mailto:support@mongodb.com>`__, call tel:+1-866-237-8815. Tel: nothing here. " +
+		"Clone git://github.com/mongodb/mongo.git or git+ssh://git@github.com/mongodb/mongo.git from ftp://ftp.example.com/pub/."
+	assert.Equal(t, []string{
+		"mailto:support@mongodb.com",
+		"tel:+1-866-237-8815",
+		"git://github.com/mongodb/mongo.git",
+		"git+ssh://git@github.com/mongodb/mongo.git",
+		"ftp://ftp.example.com/pub/",
+	}, Extract([]byte(input), OtherSchemes))
+	assert.Empty(t, Extract([]byte(input), HTTPSchemes))
+}
+
+func TestHasHTTPLink(t *testing.T) {
+	assert.True(t, HasHTTPLink("https://www.mongodb.com/docs/drivers/node/current/"))
+	assert.False(t, HasHTTPLink("/classes/Collection.html"))
+}
+
+func TestAt(t *testing.T) {
+	assert.Equal(t, "https://a.com/b", At([]byte("https://a.com/b). More text"), HTTPSchemes))
+	assert.Equal(t, "", At([]byte(" https://a.com/b"), HTTPSchemes))
+	assert.Equal(t, "", At([]byte("https://nodot/"), HTTPSchemes))
+}
+
+// FuzzExtract checks properties every extracted link must have. Its seed corpus is in testdata/fuzz.
+func FuzzExtract(f *testing.F) {
+	for _, seed := range []string{
+		"See https://www.mongodb.com/docs/manual/.",
+		"`text <https://example.com/a\n  b>`__",
+		"(https://en.wikipedia.org/wiki/Fork_(software_development))",
+		"https://[::1]:27017/ https://münchen.de/",
+		"mailto:a@b.com, tel:+1-555",
+	} {
+		f.Add(seed)
+	}
+	schemes := append(append([]string{}, HTTPSchemes...), OtherSchemes...)
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, link := range Extract([]byte(input), schemes) {
+			if !utf8.ValidString(link) {
+				t.Fatalf("%q: invalid UTF-8", link)
+			}
+			if strings.IndexFunc(link, unicode.IsSpace) >= 0 {
+				t.Fatalf("%q: contains whitespace", link)
+			}
+			colon := strings.Index(link, ":")
+			if colon < 0 || !contains(schemes, strings.ToLower(link[:colon])) {
+				t.Fatalf("%q: doesn't start with a scheme", link)
+			}
+			again := Extract([]byte(" "+link+" "), schemes)
+			if len(again) != 1 || !strings.HasPrefix(link, again[0]) {
+				t.Fatalf("%q: extracted again as %q", link, again)
+			}
+		}
+	})
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
@@ -3,19 +3,34 @@ package rst
 import (
 	"regexp"
 	"strings"
+
+	"github.com/MongoCaleb/checker/internal/parsers/links"
 )
 
 var (
 	constantRegex      = regexp.MustCompile(`<\{\+([\w\s\-_\.\d\\\/=+!@#$%^&*(\)]*)\+\}(\/[\w\s\-_\.\d\\\/=+!@#$%^&*(\)]*)>\x60`)
-	httpLinkRegex      = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9@:%_\+.~#?&//=]*)`)
-	roleRegex          = regexp.MustCompile(`:([[:alnum:]\.]+):\x60([^\x60]+)`)
+	scpLikeRegex       = regexp.MustCompile(`\bgit@[-a-zA-Z0-9.]+:[^\s<>\x60"']+`)
+	roleRegex          = regexp.MustCompile(`:((?:[[:alnum:]\.]+:)?[[:alnum:]\.]+):\x60([^\x60]+)`)
 	localRefRegex      = regexp.MustCompile(`\.\. +_([\-_=+!@#$%^&\(\)\w\d\p{P}\p{S} ]+):`)
 	sharedIncludeRegex = regexp.MustCompile(`\.\. sharedinclude::\s([\w\-_\.\d\\\/=+!@#$%^&*(\)\[\]\\\<\>'\?]+)`)
-	directiveRegex     = regexp.MustCompile(`\.\.\s([[:alnum:]]+)::\s([[:graph:] ]+)`)
+	directiveRegex     = regexp.MustCompile(`\.\.[ \t]([[:alnum:]][[:alnum:]\-_:\.]*)::(?:[ \t]+([[:graph:] ]+))?`)
+	optionRegex        = regexp.MustCompile(`^\s+:([^:\s][^:]*):(?:\s+(.*))?$`)
 )
 
 type RstHTTPLink string
 
+// RstURILink is a link with a scheme other than http(s): mailto, tel, ftp, or a git/ssh clone url.
+type RstURILink string
+
+// Scheme returns the lower-cased scheme of the link, or "ssh" for scp-like git@host:path clone urls.
+func (l RstURILink) Scheme() string {
+	i := strings.Index(string(l), ":")
+	if strings.HasPrefix(string(l), "git@") || i < 0 {
+		return "ssh"
+	}
+	return strings.ToLower(string(l)[:i])
+}
+
 type RstRole struct {
 	Target   string
 	RoleType string
@@ -37,6 +52,8 @@ type SharedInclude struct {
 type RstDirective struct {
 	Name   string
 	Target string
+	// Options are the :name: value lines right after the directive, or nil if it has none.
+	Options map[string]string
 }
 
 func parse(input []byte, re regexp.Regexp, fn func(matches []string)) {
@@ -49,11 +66,37 @@ func parse(input []byte, re regexp.Regexp, fn func(matches []string)) {
 }
 
 func ParseForHTTPLinks(input []byte) []RstHTTPLink {
-	links := make([]RstHTTPLink, 0)
-	parse(input, *httpLinkRegex, func(matches []string) {
-		links = append(links, RstHTTPLink(matches[0]))
-	})
-	return links
+	found := make([]RstHTTPLink, 0)
+	for _, link := range links.Extract(input, links.HTTPSchemes) {
+		found = append(found, RstHTTPLink(link))
+	}
+	return found
+}
+
+// ParseForURILinks finds mailto, tel, ftp, git and ssh links, including scp-like git@host:repo clone urls.
+// The git@host part of a url with a scheme, such as ssh://git@host:22/repo, isn't a link of its own, and
+// links with placeholders such as <host> or {+constant+} are left out.
+func ParseForURILinks(input []byte) []RstURILink {
+	found := make([]RstURILink, 0)
+	for _, link := range links.Extract(input, links.OtherSchemes) {
+		if !isPlaceholder(link) {
+			found = append(found, RstURILink(link))
+		}
+	}
+	text := string(input)
+	for _, loc := range scpLikeRegex.FindAllStringIndex(text, -1) {
+		token := text[strings.LastIndexAny(text[:loc[0]], " \t\r\n")+1 : loc[0]]
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)")
+		if !strings.Contains(token, "://") && !isPlaceholder(link) {
+			found = append(found, RstURILink(link))
+		}
+	}
+	return found
+}
+
+// isPlaceholder reports whether link stands for links the reader fills in, such as git@<host>:repo.git.
+func isPlaceholder(link string) bool {
+	return strings.ContainsAny(link, "<>") || strings.Contains(link, "{+")
 }
 
 func ParseForRoles(input []byte) []RstRole {
@@ -95,7 +138,7 @@ func ParseForConstants(input []byte) []RstConstant {
 }
 
 func (r *RstConstant) IsHTTPLink() bool {
-	return httpLinkRegex.Match([]byte(r.Target))
+	return links.HasHTTPLink(r.Target)
 }
 
 func ParseForLocalRefs(input []byte) []RefTarget {
@@ -115,10 +158,94 @@ func ParseForSharedIncludes(input []byte) []SharedInclude {
 	return shared
 }
 
+// ParseForDirectives finds every directive in input, with its argument as the Target, which is empty if
+// it has none, and its options.
 func ParseForDirectives(input []byte) []RstDirective {
 	directives := make([]RstDirective, 0)
-	parse(input, *directiveRegex, func(matches []string) {
-		directives = append(directives, RstDirective{Name: matches[1], Target: matches[2]})
-	})
+	text := string(input)
+	for _, loc := range directiveRegex.FindAllStringSubmatchIndex(text, -1) {
+		target := ""
+		if loc[4] >= 0 {
+			target = text[loc[4]:loc[5]]
+		}
+		directives = append(directives, RstDirective{
+			Name:    text[loc[2]:loc[3]],
+			Target:  target,
+			Options: parseOptions(text[loc[1]:]),
+		})
+	}
 	return directives
 }
+
+// parseOptions parses the option lines at the start of the directive body that follows the rest of the
+// directive's line.
+func parseOptions(rest string) map[string]string {
+	var options map[string]string
+	lines := strings.Split(rest, "\n")
+	for _, line := range lines[1:] {
+		matches := optionRegex.FindStringSubmatch(strings.TrimRight(line, " \t\r"))
+		if matches == nil {
+			break
+		}
+		if options == nil {
+			options = make(map[string]string)
+		}
+		options[matches[1]] = matches[2]
+	}
+	return options
+}
+
+// Toctree is a toctree directive: its options, such as glob, and its entries.
+type Toctree struct {
+	// Options are the :name: value lines of the toctree, or nil if it has none.
+	Options map[string]string
+	Entries []string
+}
+
+// ParseForToctrees returns every toctree in input. Its entries are the indented lines after the directive
+// that aren't options. An entry written as "Title <target>" is returned as its target.
+func ParseForToctrees(input []byte) []Toctree {
+	toctrees := make([]Toctree, 0)
+	indent := -1
+	for _, line := range strings.Split(string(input), "\n") {
+		trimmed := strings.TrimSpace(line)
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent >= 0 && trimmed != "" && lineIndent <= indent {
+			indent = -1
+		}
+		if strings.HasPrefix(trimmed, "..") && strings.TrimSpace(strings.TrimPrefix(trimmed, "..")) == "toctree::" {
+			indent = lineIndent
+			toctrees = append(toctrees, Toctree{Entries: make([]string, 0)})
+			continue
+		}
+		if indent < 0 || trimmed == "" {
+			continue
+		}
+		toctree := &toctrees[len(toctrees)-1]
+		if strings.HasPrefix(trimmed, ":") {
+			if matches := optionRegex.FindStringSubmatch(strings.TrimRight(line, " \t\r")); matches != nil {
+				if toctree.Options == nil {
+					toctree.Options = make(map[string]string)
+				}
+				toctree.Options[matches[1]] = matches[2]
+			}
+			continue
+		}
+		if strings.HasSuffix(trimmed, ">") {
+			if i := strings.LastIndex(trimmed, "<"); i >= 0 {
+				trimmed = trimmed[i+1 : len(trimmed)-1]
+			}
+		}
+		toctree.Entries = append(toctree.Entries, trimmed)
+	}
+	return toctrees
+}
+
+// ParseForToctreeEntries returns the entries of every toctree in input.
+func ParseForToctreeEntries(input []byte) []string {
+	entries := make([]string, 0)
+	for _, toctree := range ParseForToctrees(input) {
+		entries = append(entries, toctree.Entries...)
+	}
+	return entries
+}
@@ -133,6 +133,42 @@ func TestLinkParser(t *testing.T) {
 	}
 }
 
+func TestURILinkParser(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []RstURILink
+	}{{
+		input:    "no links, just a mailto mention and git@ on its own",
+		expected: []RstURILink{},
+	}, {
+		input:    "https://www.mongodb.com is not one of these",
+		expected: []RstURILink{},
+	}, {
+		input:    "Email `support <mailto:support@mongodb.com>`__ or call tel:+1-866-237-8815.",
+		expected: []RstURILink{"mailto:support@mongodb.com", "tel:+1-866-237-8815"},
+	}, {
+		input:    "Mirrors: ftp://ftp.example.com/pub/mongodb/, and sftp://files.example.com/drop",
+		expected: []RstURILink{"ftp://ftp.example.com/pub/mongodb/", "sftp://files.example.com/drop"},
+	}, {
+		input:    "git clone git://github.com/mongodb/mongo.git or git@github.com:mongodb/mongo.git or ssh://git@github.com/mongodb/mongo.git",
+		expected: []RstURILink{"git://github.com/mongodb/mongo.git", "git@github.com:mongodb/mongo.git", "ssh://git@github.com/mongodb/mongo.git"},
+	}, {
+		input:    "the user and host of ssh://git@github.com:22/mongodb/mongo.git aren't a link of their own",
+		expected: []RstURILink{"ssh://git@github.com:22/mongodb/mongo.git"},
+	}, {
+		input:    "placeholders such as git@<host>:repo.git, git@github.com:{+org+}/repo.git and ssh://{+host+}/repo.git are skipped",
+		expected: []RstURILink{},
+	}}
+	for _, test := range cases {
+		got := ParseForURILinks([]byte(test.input))
+		assert.ElementsMatch(t, test.expected, got, "ParseForURILinks(%q) should return %v, got %v", test.input, test.expected, got)
+	}
+
+	assert.Equal(t, "mailto", RstURILink("MAILTO:a@b.com").Scheme())
+	assert.Equal(t, "ssh", RstURILink("git@github.com:mongodb/mongo.git").Scheme())
+	assert.Equal(t, "git+ssh", RstURILink("git+ssh://github.com/mongodb/mongo.git").Scheme())
+}
+
 //go:embed testdata/makesGoUnhappy.txt
 var edge []byte
 
@@ -182,6 +218,9 @@ func TestRoleParser(t *testing.T) {
 	}, {
 		input:    []byte(":authaction:`find`/:authaction:`update`"),
 		expected: []RstRole{{Target: "find", RoleType: "role", Name: "authaction"}, {Target: "update", RoleType: "role", Name: "authaction"}},
+	}, {
+		input:    []byte("use :py:meth:`~pymongo.collection.Collection.find` on a :py:class:`Collection\n<pymongo.collection.Collection>`"),
+		expected: []RstRole{{Target: "~pymongo.collection.Collection.find", RoleType: "role", Name: "py:meth"}, {Target: "pymongo.collection.Collection", RoleType: "role", Name: "py:class"}},
 	}}
 
 	for _, test := range cases {
@@ -226,9 +265,18 @@ func TestFindDirectives(t *testing.T) {
 		expected: []RstDirective{},
 	}, {
 		input:    []byte(".. code-block::"),
-		expected: []RstDirective{},
+		expected: []RstDirective{{Name: "code-block"}},
 	}, {
-		input:    []byte(".. important::"),
+		input:    []byte(".. important::\n   Text of the admonition."),
+		expected: []RstDirective{{Name: "important"}},
+	}, {
+		input:    []byte(".. code-block:: python\n   :linenos:\n"),
+		expected: []RstDirective{{Name: "code-block", Target: "python", Options: map[string]string{"linenos": ""}}},
+	}, {
+		input:    []byte(".. mongodb:method:: db.collection.find()"),
+		expected: []RstDirective{{Name: "mongodb:method", Target: "db.collection.find()"}},
+	}, {
+		input:    []byte(".. _label:\n.. |version| replace:: 6.0"),
 		expected: []RstDirective{},
 	}, {
 		input:    []byte(".. include:: /includes/foo.txt"),
@@ -245,6 +293,17 @@ func TestFindDirectives(t *testing.T) {
 	}, {
 		input:    []byte(".. method:: sh.removeShardTag(shard, tag)"),
 		expected: []RstDirective{{Name: "method", Target: "sh.removeShardTag(shard, tag)"}},
+	}, {
+		input: []byte(".. literalinclude:: /includes/code/connect.py\n   :language: python\n   :start-after: start connect\n   :dedent:\n\n   :lines: 1-3\n"),
+		expected: []RstDirective{{Name: "literalinclude", Target: "/includes/code/connect.py", Options: map[string]string{
+			"language": "python", "start-after": "start connect", "dedent": "",
+		}}},
+	}, {
+		input: []byte("- .. figure:: /images/compass.png\n     :alt: Compass\n\n  .. image:: /images/logo.png\n"),
+		expected: []RstDirective{
+			{Name: "figure", Target: "/images/compass.png", Options: map[string]string{"alt": "Compass"}},
+			{Name: "image", Target: "/images/logo.png"},
+		},
 	}}
 
 	for _, test := range cases {
@@ -252,3 +311,51 @@ func TestFindDirectives(t *testing.T) {
 		assert.ElementsMatch(t, test.expected, got, "ParseForDirectives(%q) should return %v, got %v", test.input, test.expected, got)
 	}
 }
+
+func TestToctreeParser(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{{
+		input:    "",
+		expected: []string{},
+	}, {
+		input:    ".. toctree::\n   :titlesonly:\n   :hidden:\n\n   /install\n   Connect </tutorial/connect>\n   upgrade\n\nSome text.\n\n   /not-an-entry",
+		expected: []string{"/install", "/tutorial/connect", "upgrade"},
+	}, {
+		input:    "Intro\n\n.. toctree::\n   :maxdepth: 1\n\n   Tutorials </tutorials>\n\n.. toctree::\n\n   self\n   Manual <https://www.mongodb.com/docs/manual/>\n",
+		expected: []string{"/tutorials", "self", "https://www.mongodb.com/docs/manual/"},
+	}, {
+		input:    "- Item\n\n  .. toctree::\n\n     nested\n\n- Other item\n",
+		expected: []string{"nested"},
+	}}
+
+	for _, test := range cases {
+		got := ParseForToctreeEntries([]byte(test.input))
+		assert.Equal(t, test.expected, got, "ParseForToctreeEntries(%q) should return %v, got %v", test.input, test.expected, got)
+	}
+}
+
+func TestToctreeOptions(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []Toctree
+	}{{
+		input:    "",
+		expected: []Toctree{},
+	}, {
+		input: ".. toctree::\n   :titlesonly:\n   :maxdepth: 2\n\n   /install\n\n.. toctree::\n   :glob:\n\n   /guides/*\n",
+		expected: []Toctree{
+			{Options: map[string]string{"titlesonly": "", "maxdepth": "2"}, Entries: []string{"/install"}},
+			{Options: map[string]string{"glob": ""}, Entries: []string{"/guides/*"}},
+		},
+	}, {
+		input:    ".. toctree::\n\n   self\n",
+		expected: []Toctree{{Entries: []string{"self"}}},
+	}}
+
+	for _, test := range cases {
+		got := ParseForToctrees([]byte(test.input))
+		assert.Equal(t, test.expected, got, "ParseForToctrees(%q) should return %v, got %v", test.input, test.expected, got)
+	}
+}
@@ -0,0 +1,318 @@
+package sources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CheckerConfig contains checker's own settings, read from config/link_checker_config.json.
+type CheckerConfig struct {
+	Rewrites []RewriteRule  `json:"rewrites"`
+	Soft404s []Soft404Rule  `json:"soft404"`
+	Hosts    []HostSettings `json:"hosts"`
+	// Severity maps diagnostic kinds, such as "timeout" or "redirect", to "error", "warning", "info"
+	// or "ignore".
+	Severity map[string]string `json:"severity"`
+	// CertExpiryDays is how long before a linked host's certificate expires to start warning about it.
+	CertExpiryDays int `json:"cert_expiry_days"`
+	// InternalHosts are hosts, or *.example.com patterns, that readers of the docs can't reach.
+	InternalHosts []string `json:"internal_hosts"`
+	// SecretParams are query parameters, besides the usual token, key and password names, whose values
+	// must not be published.
+	SecretParams []string `json:"secret_params"`
+	// BaseURL is where the docset is published, such as https://www.mongodb.com/docs/compass/current/.
+	BaseURL string `json:"base_url"`
+	// Policy holds the conventions every link must follow, such as hosts that must not be linked to.
+	Policy *Policy `json:"policy"`
+	// UnusedAllow lists labels, pages and include files that `checker report unused` should leave out,
+	// such as intentional landing pages. Each is a path.Match pattern.
+	UnusedAllow []string `json:"unused_allow"`
+}
+
+const defaultCertExpiryDays = 30
+
+// SeverityIgnore drops diagnostics of a kind from the report entirely.
+const SeverityIgnore = "ignore"
+
+var severities = []string{"error", "warning", "info", SeverityIgnore}
+
+// RewriteRule replaces urls matching Match with Replace. Replace may refer to capture groups as $1 or ${name}.
+type RewriteRule struct {
+	Match   string `json:"match"`
+	Replace string `json:"replace"`
+	Reason  string `json:"reason"`
+
+	re *regexp.Regexp
+}
+
+func NewCheckerConfig(input []byte) (*CheckerConfig, error) {
+	var cfg CheckerConfig
+	if len(input) == 0 {
+		return &cfg, nil
+	}
+	if err := json.Unmarshal(input, &cfg); err != nil {
+		return nil, err
+	}
+	for kind, severity := range cfg.Severity {
+		severity = strings.ToLower(severity)
+		if !containsString(severities, severity) {
+			return nil, fmt.Errorf("severity for %q must be one of %s, got %q", kind, strings.Join(severities, ", "), severity)
+		}
+		cfg.Severity[kind] = severity
+	}
+	for i := range cfg.Hosts {
+		if err := cfg.Hosts[i].compile(); err != nil {
+			return nil, fmt.Errorf("host settings for %q: %w", cfg.Hosts[i].Host+cfg.Hosts[i].Pattern, err)
+		}
+	}
+	for i := range cfg.Soft404s {
+		if err := cfg.Soft404s[i].compile(); err != nil {
+			return nil, fmt.Errorf("soft404 rule for %q: %w", cfg.Soft404s[i].Host, err)
+		}
+	}
+	if cfg.Policy != nil {
+		for i := range cfg.Policy.Rules {
+			if err := cfg.Policy.Rules[i].compile(); err != nil {
+				return nil, fmt.Errorf("policy rule %q: %w", cfg.Policy.Rules[i].Match, err)
+			}
+		}
+	}
+	for i := range cfg.Rewrites {
+		re, err := regexp.Compile(cfg.Rewrites[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("rewrite rule %q: %w", cfg.Rewrites[i].Match, err)
+		}
+		cfg.Rewrites[i].re = re
+	}
+	return &cfg, nil
+}
+
+// CertificateExpiryDays returns CertExpiryDays, or the default of 30 days if it isn't set.
+func (cfg *CheckerConfig) CertificateExpiryDays() int {
+	if cfg == nil || cfg.CertExpiryDays <= 0 {
+		return defaultCertExpiryDays
+	}
+	return cfg.CertExpiryDays
+}
+
+// SeverityFor returns the configured severity for diagnostics of kind, if there is one.
+func (cfg *CheckerConfig) SeverityFor(kind string) (string, bool) {
+	if cfg == nil || kind == "" {
+		return "", false
+	}
+	severity, ok := cfg.Severity[kind]
+	return severity, ok
+}
+
+// IsInternalHost reports whether host matches one of InternalHosts.
+func (cfg *CheckerConfig) IsInternalHost(host string) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, pattern := range cfg.InternalHosts {
+		if MatchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecretParam reports whether the query parameter name is one of SecretParams.
+func (cfg *CheckerConfig) IsSecretParam(name string) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, secret := range cfg.SecretParams {
+		if strings.EqualFold(secret, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Rewrite applies every matching rewrite rule, then every policy rule with a rewrite, to url in order and
+// returns the result along with the reasons of the rules that changed it.
+func (cfg *CheckerConfig) Rewrite(url string) (string, []string) {
+	reasons := make([]string, 0)
+	if cfg == nil {
+		return url, reasons
+	}
+	for _, rule := range cfg.Rewrites {
+		if rule.re == nil || !rule.re.MatchString(url) {
+			continue
+		}
+		rewritten := rule.re.ReplaceAllString(url, rule.Replace)
+		if rewritten != url {
+			url = rewritten
+			reasons = append(reasons, rule.Reason)
+		}
+	}
+	return cfg.Policy.rewrite(url, reasons)
+}
+
+// Soft404Rule describes how to recognize a host's "not found" page when it is served with a 200.
+type Soft404Rule struct {
+	Host         string  `json:"host"`
+	Title        string  `json:"title"`
+	Body         string  `json:"body"`
+	RootRedirect bool    `json:"root_redirect"`
+	Probe        bool    `json:"probe"`
+	Similarity   float64 `json:"similarity"`
+
+	titleRe *regexp.Regexp
+	bodyRe  *regexp.Regexp
+}
+
+const defaultSimilarity = 0.9
+
+func (r *Soft404Rule) compile() error {
+	var err error
+	if r.Title != "" {
+		if r.titleRe, err = regexp.Compile(r.Title); err != nil {
+			return err
+		}
+	}
+	if r.Body != "" {
+		if r.bodyRe, err = regexp.Compile(r.Body); err != nil {
+			return err
+		}
+	}
+	if r.Similarity <= 0 || r.Similarity > 1 {
+		r.Similarity = defaultSimilarity
+	}
+	return nil
+}
+
+// NeedsBody reports whether any of the rule's heuristics have to look at the page itself.
+func (r *Soft404Rule) NeedsBody() bool {
+	return r.titleRe != nil || r.bodyRe != nil || r.Probe
+}
+
+func (r *Soft404Rule) MatchTitle(title string) bool {
+	return r.titleRe != nil && r.titleRe.MatchString(title)
+}
+
+func (r *Soft404Rule) MatchBody(body []byte) bool {
+	return r.bodyRe != nil && r.bodyRe.Match(body)
+}
+
+// Soft404For returns the first soft-404 rule whose host pattern matches host, or nil.
+func (cfg *CheckerConfig) Soft404For(host string) *Soft404Rule {
+	if cfg == nil {
+		return nil
+	}
+	for i := range cfg.Soft404s {
+		if MatchHost(cfg.Soft404s[i].Host, host) {
+			return &cfg.Soft404s[i]
+		}
+	}
+	return nil
+}
+
+// MatchHost reports whether host matches pattern. A pattern of "*.example.com" matches any subdomain of
+// example.com; any other pattern must equal the host. Both are compared case-insensitively.
+func MatchHost(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
+
+// HostSettings customizes the requests checker makes for urls on a host, or for urls matching a pattern.
+// Header values can refer to environment variables as $NAME or ${NAME}, which keeps tokens out of the config.
+type HostSettings struct {
+	Host      string            `json:"host"`
+	Pattern   string            `json:"pattern"`
+	Accept    []int             `json:"accept"`
+	Headers   map[string]string `json:"headers"`
+	UserAgent string            `json:"user_agent"`
+	Timeout   string            `json:"timeout"`
+	Method    string            `json:"method"`
+
+	re      *regexp.Regexp
+	timeout time.Duration
+}
+
+func (h *HostSettings) compile() error {
+	if h.Host == "" && h.Pattern == "" {
+		return fmt.Errorf("one of host or pattern is required")
+	}
+	var err error
+	if h.Pattern != "" {
+		if h.re, err = regexp.Compile(h.Pattern); err != nil {
+			return err
+		}
+	}
+	if h.Timeout != "" {
+		if h.timeout, err = time.ParseDuration(h.Timeout); err != nil {
+			return err
+		}
+	}
+	h.Method = strings.ToUpper(h.Method)
+	if h.Method != "" && h.Method != "GET" && h.Method != "HEAD" {
+		return fmt.Errorf("method must be GET or HEAD, got %q", h.Method)
+	}
+	return nil
+}
+
+func (h *HostSettings) matches(uri string, host string) bool {
+	if h.Host != "" && !MatchHost(h.Host, host) {
+		return false
+	}
+	if h.re != nil && !h.re.MatchString(uri) {
+		return false
+	}
+	return true
+}
+
+// Accepts reports whether a response with code counts as reachable for these urls.
+func (h *HostSettings) Accepts(code int) bool {
+	if h == nil {
+		return false
+	}
+	for _, c := range h.Accept {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestTimeout returns the configured timeout, or 0 to use the default.
+func (h *HostSettings) RequestTimeout() time.Duration {
+	if h == nil {
+		return 0
+	}
+	return h.timeout
+}
+
+// HostSettingsFor returns the first host settings entry that applies to uri, or nil.
+func (cfg *CheckerConfig) HostSettingsFor(uri string) *HostSettings {
+	if cfg == nil {
+		return nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil
+	}
+	for i := range cfg.Hosts {
+		if cfg.Hosts[i].matches(uri, u.Hostname()) {
+			return &cfg.Hosts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const checkerConfigInput = `
+{
+    "rewrites": [
+        {
+            "match": "^https?://docs\\.mongodb\\.com/(.*)$",
+            "replace": "https://www.mongodb.com/docs/$1",
+            "reason": "docs moved to www.mongodb.com"
+        },
+        {
+            "match": "/manual/v4\\.0/",
+            "replace": "/manual/",
+            "reason": "link to the current manual"
+        }
+    ]
+}
+`
+
+func TestCheckerConfigRewrite(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		input    string
+		expected string
+		reasons  []string
+	}{{
+		input:    "https://docs.mongodb.com/drivers/go/",
+		expected: "https://www.mongodb.com/docs/drivers/go/",
+		reasons:  []string{"docs moved to www.mongodb.com"},
+	}, {
+		input:    "http://docs.mongodb.com/manual/v4.0/core/",
+		expected: "https://www.mongodb.com/docs/manual/core/",
+		reasons:  []string{"docs moved to www.mongodb.com", "link to the current manual"},
+	}, {
+		input:    "https://github.com/mongodb/mongo-go-driver",
+		expected: "https://github.com/mongodb/mongo-go-driver",
+		reasons:  []string{},
+	}}
+
+	for _, c := range cases {
+		got, reasons := cfg.Rewrite(c.input)
+		assert.Equal(t, c.expected, got, "Rewrite(%q)", c.input)
+		assert.Equal(t, c.reasons, reasons, "Rewrite(%q)", c.input)
+	}
+}
+
+func TestCheckerConfigEmpty(t *testing.T) {
+	cfg, err := NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	got, reasons := cfg.Rewrite("https://www.mongodb.com")
+	assert.Equal(t, "https://www.mongodb.com", got)
+	assert.Empty(t, reasons)
+}
+
+func TestCheckerConfigBadRule(t *testing.T) {
+	_, err := NewCheckerConfig([]byte(`{"rewrites": [{"match": "(", "replace": ""}]}`))
+	assert.Error(t, err)
+}
+
+func TestCheckerConfigSoft404(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"soft404": [
+		{"host": "docs.vendor.com", "title": "(?i)page not found", "root_redirect": true},
+		{"host": "*.example.org", "body": "no longer exists", "probe": true, "similarity": 0.75}
+	]}`))
+	assert.NoError(t, err)
+
+	rule := cfg.Soft404For("DOCS.vendor.com")
+	if assert.NotNil(t, rule) {
+		assert.True(t, rule.MatchTitle("Page Not Found | Vendor"))
+		assert.False(t, rule.MatchBody([]byte("no longer exists")))
+		assert.True(t, rule.NeedsBody())
+		assert.Equal(t, 0.9, rule.Similarity)
+	}
+
+	rule = cfg.Soft404For("api.example.org")
+	if assert.NotNil(t, rule) {
+		assert.True(t, rule.MatchBody([]byte("<p>This page no longer exists.</p>")))
+		assert.False(t, rule.MatchTitle("anything"))
+		assert.Equal(t, 0.75, rule.Similarity)
+	}
+
+	assert.Nil(t, cfg.Soft404For("example.org"))
+	assert.Nil(t, cfg.Soft404For("www.mongodb.com"))
+}
+
+func TestMatchHost(t *testing.T) {
+	cases := []struct {
+		pattern string
+		host    string
+		match   bool
+	}{
+		{pattern: "www.linkedin.com", host: "www.linkedin.com", match: true},
+		{pattern: "www.linkedin.com", host: "linkedin.com", match: false},
+		{pattern: "*.linkedin.com", host: "www.LinkedIn.com", match: true},
+		{pattern: "*.linkedin.com", host: "linkedin.com", match: false},
+		{pattern: "*.linkedin.com", host: "notlinkedin.com", match: false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.match, MatchHost(c.pattern, c.host), "MatchHost(%q, %q)", c.pattern, c.host)
+	}
+}
+
+func TestCheckerConfigHostSettings(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"hosts": [
+		{"host": "*.linkedin.com", "accept": [999], "method": "head"},
+		{"pattern": "^https://github\\.com/10gen/", "accept": [404], "headers": {"Authorization": "token ${GITHUB_TOKEN}"}, "timeout": "5s"},
+		{"host": "github.com", "user_agent": "checker"}
+	]}`))
+	assert.NoError(t, err)
+
+	linkedin := cfg.HostSettingsFor("https://www.linkedin.com/company/mongodbinc")
+	if assert.NotNil(t, linkedin) {
+		assert.True(t, linkedin.Accepts(999))
+		assert.False(t, linkedin.Accepts(404))
+		assert.Equal(t, "HEAD", linkedin.Method)
+		assert.Equal(t, time.Duration(0), linkedin.RequestTimeout())
+	}
+
+	private := cfg.HostSettingsFor("https://github.com/10gen/docs-shared")
+	if assert.NotNil(t, private) {
+		assert.True(t, private.Accepts(404))
+		assert.Equal(t, 5*time.Second, private.RequestTimeout())
+		assert.Equal(t, "token ${GITHUB_TOKEN}", private.Headers["Authorization"])
+	}
+
+	public := cfg.HostSettingsFor("https://github.com/mongodb/mongo")
+	if assert.NotNil(t, public) {
+		assert.Equal(t, "checker", public.UserAgent)
+		assert.False(t, public.Accepts(404))
+	}
+
+	assert.Nil(t, cfg.HostSettingsFor("https://www.mongodb.com/docs/"))
+
+	var none *CheckerConfig
+	assert.Nil(t, none.HostSettingsFor("https://www.linkedin.com"))
+	assert.False(t, none.HostSettingsFor("https://www.linkedin.com").Accepts(999))
+}
+
+func TestCheckerConfigBadHostSettings(t *testing.T) {
+	for _, input := range []string{
+		`{"hosts": [{"accept": [403]}]}`,
+		`{"hosts": [{"host": "a.com", "timeout": "soon"}]}`,
+		`{"hosts": [{"host": "a.com", "method": "POST"}]}`,
+		`{"hosts": [{"pattern": "("}]}`,
+	} {
+		_, err := NewCheckerConfig([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestCheckerConfigSeverity(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"severity": {"timeout": "Warning", "redirect": "ignore"}}`))
+	assert.NoError(t, err)
+
+	severity, ok := cfg.SeverityFor("timeout")
+	assert.True(t, ok)
+	assert.Equal(t, "warning", severity)
+	severity, ok = cfg.SeverityFor("redirect")
+	assert.True(t, ok)
+	assert.Equal(t, SeverityIgnore, severity)
+	_, ok = cfg.SeverityFor("dns-error")
+	assert.False(t, ok)
+
+	_, err = NewCheckerConfig([]byte(`{"severity": {"timeout": "fatal"}}`))
+	assert.Error(t, err)
+
+	var none *CheckerConfig
+	_, ok = none.SeverityFor("timeout")
+	assert.False(t, ok)
+}
+
+func TestCheckerConfigCertExpiryDays(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"cert_expiry_days": 14}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 14, cfg.CertificateExpiryDays())
+
+	var none *CheckerConfig
+	assert.Equal(t, 30, none.CertificateExpiryDays())
+}
+
+func TestCheckerConfigLeaks(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"internal_hosts": ["*.corp.mongodb.com", "wiki.10gen.com"], "secret_params": ["session"]}`))
+	assert.NoError(t, err)
+	assert.True(t, cfg.IsInternalHost("jira.corp.mongodb.com"))
+	assert.True(t, cfg.IsInternalHost("WIKI.10gen.com"))
+	assert.False(t, cfg.IsInternalHost("www.mongodb.com"))
+	assert.True(t, cfg.IsSecretParam("Session"))
+	assert.False(t, cfg.IsSecretParam("page"))
+
+	var none *CheckerConfig
+	assert.False(t, none.IsInternalHost("wiki.10gen.com"))
+	assert.False(t, none.IsSecretParam("session"))
+}
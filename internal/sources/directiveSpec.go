@@ -0,0 +1,150 @@
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArgumentType is the type of a directive's argument, or of one of its options, in rstspec.toml: the name
+// of a primitive type such as string or flag, or of an enum. If there are several, a value of any of them
+// is valid. A type ending in ? may be left out.
+type ArgumentType []string
+
+// DirectiveSpec is what rstspec.toml says about a directive, with what it inherits already applied.
+type DirectiveSpec struct {
+	ArgumentType ArgumentType
+	Options      map[string]ArgumentType
+	Deprecated   bool
+}
+
+var (
+	lengthRegex  = regexp.MustCompile(`^\d+(\.\d+)?\s*(em|ex|px|in|cm|mm|pt|pc|%)?$`)
+	linenosRegex = regexp.MustCompile(`^\d+(\s*-\s*\d*)?(\s*,\s*\d+(\s*-\s*\d*)?)*$`)
+)
+
+// Required reports whether a value of the type must be given.
+func (t ArgumentType) Required() bool {
+	if len(t) == 0 {
+		return false
+	}
+	for _, name := range t {
+		if strings.HasSuffix(name, "?") || name == "flag" {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns an error describing why value isn't of the type, using enums to look up enum types.
+// Types that aren't known accept any value.
+func (t ArgumentType) Check(value string, enums map[string][]string) error {
+	if len(t) == 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(t))
+	for _, name := range t {
+		reason, ok := checkValue(strings.TrimSuffix(name, "?"), strings.TrimSpace(value), enums)
+		if ok {
+			return nil
+		}
+		reasons = append(reasons, reason)
+	}
+	return fmt.Errorf("%s", strings.Join(reasons, ", or "))
+}
+
+func checkValue(typ string, value string, enums map[string][]string) (string, bool) {
+	switch typ {
+	case "flag":
+		return "takes no value", value == ""
+	case "boolean":
+		lower := strings.ToLower(value)
+		return "must be true or false", lower == "true" || lower == "false"
+	case "integer":
+		_, err := strconv.Atoi(value)
+		return "must be an integer", err == nil
+	case "nonnegative_integer":
+		n, err := strconv.Atoi(value)
+		return "must be an integer of 0 or more", err == nil && n >= 0
+	case "length":
+		return "must be a length, such as 100px or 50%", lengthRegex.MatchString(value)
+	case "linenos":
+		return "must be line numbers, such as 1-3,5", linenosRegex.MatchString(value)
+	case "string", "path", "uri":
+		return "needs a value", value != ""
+	}
+	if values, ok := enums[typ]; ok {
+		return fmt.Sprintf("must be one of %s", strings.Join(values, ", ")), containsString(values, value)
+	}
+	return "", true
+}
+
+// Directive returns the spec of the directive name. A directive of a domain, such as mongodb:method, may
+// be used without its domain.
+func (r *RstSpec) Directive(name string) (DirectiveSpec, bool) {
+	if spec, ok := r.Directives[name]; ok {
+		return spec, true
+	}
+	if strings.Contains(name, ":") {
+		return DirectiveSpec{}, false
+	}
+	keys := make([]string, 0)
+	for key := range r.Directives {
+		if strings.HasSuffix(key, ":"+name) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return DirectiveSpec{}, false
+	}
+	sort.Strings(keys)
+	return r.Directives[keys[0]], true
+}
+
+// argumentType reads an argument type written as a name or a list of names.
+func argumentType(raw interface{}) ArgumentType {
+	switch v := raw.(type) {
+	case string:
+		return ArgumentType{v}
+	case []interface{}:
+		t := make(ArgumentType, 0, len(v))
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				t = append(t, s)
+			}
+		}
+		return t
+	}
+	return nil
+}
+
+// directiveSpec builds the spec of the directive name from the raw directives, applying what it inherits.
+// seen guards against directives that inherit from each other.
+func directiveSpec(name string, raw map[string]interface{}, seen map[string]bool) DirectiveSpec {
+	spec := DirectiveSpec{Options: make(map[string]ArgumentType)}
+	fields, ok := raw[name].(map[string]interface{})
+	if !ok || seen[name] {
+		return spec
+	}
+	seen[name] = true
+
+	if parent, ok := fields["inherit"].(string); ok {
+		inherited := directiveSpec(parent, raw, seen)
+		spec.ArgumentType = inherited.ArgumentType
+		for option, t := range inherited.Options {
+			spec.Options[option] = t
+		}
+	}
+	if t, ok := fields["argument_type"]; ok {
+		spec.ArgumentType = argumentType(t)
+	}
+	if options, ok := fields["options"].(map[string]interface{}); ok {
+		for option, t := range options {
+			spec.Options[option] = argumentType(t)
+		}
+	}
+	spec.Deprecated, _ = fields["deprecated"].(bool)
+	return spec
+}
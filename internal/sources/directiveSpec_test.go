@@ -0,0 +1,99 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const directiveRstSpec = `
+[enum]
+user_level = ["beginner", "intermediate", "advanced"]
+
+[directive._code-base]
+argument_type = "string"
+options.linenos = "flag"
+options.emphasize-lines = "linenos"
+
+[directive.code-block]
+inherit = "_code-base"
+options.copyable = "boolean"
+
+[directive.image]
+argument_type = "uri"
+options.width = "length"
+options.scale = ["nonnegative_integer", "length"]
+
+[directive.note]
+argument_type = "string?"
+options.level = "user_level"
+
+[directive."mongodb:method"]
+argument_type = "string"
+
+[directive.loop]
+inherit = "loop"
+options.depth = "integer"
+`
+
+func TestDirectiveSpec(t *testing.T) {
+	spec := NewRoleMap([]byte(directiveRstSpec))
+
+	assert.Equal(t, map[string][]string{"user_level": {"beginner", "intermediate", "advanced"}}, spec.Enums)
+	assert.Equal(t, DirectiveSpec{
+		ArgumentType: ArgumentType{"string"},
+		Options: map[string]ArgumentType{
+			"linenos":         {"flag"},
+			"emphasize-lines": {"linenos"},
+			"copyable":        {"boolean"},
+		},
+	}, spec.Directives["code-block"])
+	assert.Equal(t, DirectiveSpec{Options: map[string]ArgumentType{"depth": {"integer"}}}, spec.Directives["loop"])
+
+	method, ok := spec.Directive("method")
+	assert.True(t, ok)
+	assert.Equal(t, ArgumentType{"string"}, method.ArgumentType)
+	_, ok = spec.Directive("other:method")
+	assert.False(t, ok)
+	_, ok = spec.Directive("nope")
+	assert.False(t, ok)
+}
+
+func TestArgumentType(t *testing.T) {
+	enums := map[string][]string{"user_level": {"beginner", "intermediate", "advanced"}}
+	cases := []struct {
+		typ      ArgumentType
+		value    string
+		required bool
+		err      string
+	}{
+		{ArgumentType{"flag"}, "", false, ""},
+		{ArgumentType{"flag"}, "yes", false, "takes no value"},
+		{ArgumentType{"boolean"}, "True", true, ""},
+		{ArgumentType{"boolean"}, "maybe", true, "must be true or false"},
+		{ArgumentType{"integer"}, "-3", true, ""},
+		{ArgumentType{"nonnegative_integer"}, "-3", true, "must be an integer of 0 or more"},
+		{ArgumentType{"length"}, "50%", true, ""},
+		{ArgumentType{"length"}, "wide", true, "must be a length, such as 100px or 50%"},
+		{ArgumentType{"linenos"}, "1-3, 5, 8-", true, ""},
+		{ArgumentType{"linenos"}, "first", true, "must be line numbers, such as 1-3,5"},
+		{ArgumentType{"string"}, "", true, "needs a value"},
+		{ArgumentType{"string?"}, "", false, "needs a value"},
+		{ArgumentType{"user_level"}, "beginner", true, ""},
+		{ArgumentType{"user_level"}, "expert", true, "must be one of beginner, intermediate, advanced"},
+		{ArgumentType{"nonnegative_integer", "length"}, "50%", true, ""},
+		{ArgumentType{"nonnegative_integer", "length"}, "half", true, "must be an integer of 0 or more, or must be a length, such as 100px or 50%"},
+		{ArgumentType{"callable"}, "anything", true, ""},
+		{nil, "anything", false, ""},
+	}
+
+	for _, test := range cases {
+		err := test.typ.Check(test.value, enums)
+		if test.err == "" {
+			assert.NoError(t, err, "%v should accept %q", test.typ, test.value)
+		} else {
+			assert.EqualError(t, err, test.err, "%v should reject %q", test.typ, test.value)
+		}
+		assert.Equal(t, test.required, test.typ.Required(), "%v.Required()", test.typ)
+	}
+}
@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// KindPolicy marks links that match a policy rule.
+	KindPolicy = "policy"
+	// KindDeniedHost marks links to a host on the policy's deny list.
+	KindDeniedHost = "denied-host"
+	// KindHostNotAllowed marks links to a host missing from the policy's allow list.
+	KindHostNotAllowed = "host-not-allowed"
+)
+
+// Policy holds the conventions every link in the docset must follow. A host matching DenyHosts is never
+// allowed; if AllowHosts is set, links must be to one of its hosts. Hosts are exact, or *.example.com
+// for any subdomain. Rules flag urls matching a regular expression.
+type Policy struct {
+	AllowHosts []string     `json:"allow_hosts"`
+	DenyHosts  []string     `json:"deny_hosts"`
+	Rules      []PolicyRule `json:"rules"`
+}
+
+// PolicyRule reports urls matching Match with Message at Severity, "error" by default. If Rewrite is set,
+// it is the url that should be used instead, and may refer to capture groups as $1 or ${name}.
+type PolicyRule struct {
+	Match    string `json:"match"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Rewrite  string `json:"rewrite"`
+
+	re *regexp.Regexp
+}
+
+// ruleSeverities are the severities a rule can have; a rule can't be ignored.
+var ruleSeverities = []string{"error", "warning", "info"}
+
+// Violation is a way a link breaks the policy. Suggestion is the url to use instead, if a rule has one.
+type Violation struct {
+	Kind       string
+	Severity   string
+	Message    string
+	Suggestion string
+}
+
+func (r *PolicyRule) compile() error {
+	var err error
+	if r.re, err = regexp.Compile(r.Match); err != nil {
+		return err
+	}
+	if r.Severity == "" {
+		r.Severity = "error"
+	}
+	r.Severity = strings.ToLower(r.Severity)
+	if !containsString(ruleSeverities, r.Severity) {
+		return fmt.Errorf("severity must be one of %s, got %q", strings.Join(ruleSeverities, ", "), r.Severity)
+	}
+	return nil
+}
+
+// Evaluate returns every way uri breaks the configured policy.
+func (cfg *CheckerConfig) Evaluate(uri string) []Violation {
+	if cfg == nil {
+		return make([]Violation, 0)
+	}
+	return cfg.Policy.Evaluate(uri)
+}
+
+// Evaluate returns every way uri breaks the policy.
+func (p *Policy) Evaluate(uri string) []Violation {
+	violations := make([]Violation, 0)
+	if p == nil {
+		return violations
+	}
+	if u, err := url.Parse(uri); err == nil && u.Hostname() != "" {
+		host := u.Hostname()
+		if matchesAny(p.DenyHosts, host) {
+			violations = append(violations, Violation{Kind: KindDeniedHost, Severity: "error", Message: fmt.Sprintf("links to %s are not allowed", host)})
+		} else if len(p.AllowHosts) > 0 && !matchesAny(p.AllowHosts, host) {
+			violations = append(violations, Violation{Kind: KindHostNotAllowed, Severity: "error", Message: fmt.Sprintf("%s is not an allowed host", host)})
+		}
+	}
+	for _, rule := range p.Rules {
+		if rule.re == nil || !rule.re.MatchString(uri) {
+			continue
+		}
+		v := Violation{Kind: KindPolicy, Severity: rule.Severity, Message: rule.Message}
+		if rule.Rewrite != "" {
+			v.Suggestion = rule.re.ReplaceAllString(uri, rule.Rewrite)
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// rewrite applies every policy rule with a Rewrite to url in order, like CheckerConfig.Rewrite.
+func (p *Policy) rewrite(url string, reasons []string) (string, []string) {
+	if p == nil {
+		return url, reasons
+	}
+	for _, rule := range p.Rules {
+		if rule.re == nil || rule.Rewrite == "" || !rule.re.MatchString(url) {
+			continue
+		}
+		rewritten := rule.re.ReplaceAllString(url, rule.Rewrite)
+		if rewritten != url {
+			url = rewritten
+			reasons = append(reasons, rule.Message)
+		}
+	}
+	return url, reasons
+}
+
+func matchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if MatchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const policyConfig = `{
+	"policy": {
+		"allow_hosts": ["*.mongodb.com", "github.com"],
+		"deny_hosts": ["bit.ly", "docs.mongodb.com"],
+		"rules": [
+			{
+				"match": "^https?://docs\\.mongodb\\.com/(.*)$",
+				"message": "docs moved to www.mongodb.com",
+				"rewrite": "https://www.mongodb.com/docs/$1"
+			},
+			{
+				"match": "^https://www\\.mongodb\\.com/docs/v(2\\.\\d|3\\.[0-4])/",
+				"message": "link to a supported version of the manual",
+				"severity": "Warning"
+			}
+		]
+	}
+}`
+
+func TestPolicyEvaluate(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(policyConfig))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		input      string
+		violations []Violation
+	}{{
+		input:      "https://www.mongodb.com/docs/manual/",
+		violations: []Violation{},
+	}, {
+		input:      "mailto:docs@mongodb.com",
+		violations: []Violation{},
+	}, {
+		input: "https://bit.ly/3xyz",
+		violations: []Violation{
+			{Kind: KindDeniedHost, Severity: "error", Message: "links to bit.ly are not allowed"},
+		},
+	}, {
+		input: "https://www.example.com/",
+		violations: []Violation{
+			{Kind: KindHostNotAllowed, Severity: "error", Message: "www.example.com is not an allowed host"},
+		},
+	}, {
+		input: "https://docs.mongodb.com/manual/core/",
+		violations: []Violation{
+			{Kind: KindDeniedHost, Severity: "error", Message: "links to docs.mongodb.com are not allowed"},
+			{Kind: KindPolicy, Severity: "error", Message: "docs moved to www.mongodb.com", Suggestion: "https://www.mongodb.com/docs/manual/core/"},
+		},
+	}, {
+		input: "https://www.mongodb.com/docs/v3.2/reference/",
+		violations: []Violation{
+			{Kind: KindPolicy, Severity: "warning", Message: "link to a supported version of the manual"},
+		},
+	}}
+	for _, c := range cases {
+		assert.Equal(t, c.violations, cfg.Evaluate(c.input), c.input)
+	}
+
+	var none *CheckerConfig
+	assert.Empty(t, none.Evaluate("https://bit.ly/3xyz"))
+}
+
+func TestPolicyRewrite(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(policyConfig))
+	assert.NoError(t, err)
+	got, reasons := cfg.Rewrite("http://docs.mongodb.com/manual/")
+	assert.Equal(t, "https://www.mongodb.com/docs/manual/", got)
+	assert.Equal(t, []string{"docs moved to www.mongodb.com"}, reasons)
+}
+
+func TestPolicyInvalid(t *testing.T) {
+	_, err := NewCheckerConfig([]byte(`{"policy": {"rules": [{"match": "(", "message": "x"}]}}`))
+	assert.Error(t, err)
+	_, err = NewCheckerConfig([]byte(`{"policy": {"rules": [{"match": "x", "message": "x", "severity": "ignore"}]}}`))
+	assert.Error(t, err)
+}
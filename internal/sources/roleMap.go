@@ -1,6 +1,8 @@
 package sources
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -11,17 +13,22 @@ type RawRstSpec struct {
 	Roles      map[string]interface{} `toml:"role"`
 	RstObjects map[string]interface{} `toml:"rstobject"`
 	Directives map[string]interface{} `toml:"directive"`
+	Enums      map[string]interface{} `toml:"enum"`
 }
 
 type RstSpec struct {
 	Roles      RolesMap
-	RawRoles   map[string]bool
-	Directives map[string]bool
+	Directives map[string]DirectiveSpec
 	RstObjects map[string]bool
+	// RstObjectTypes maps the name of each rstobject, such as method, to its domain-qualified names, such
+	// as mongodb:method.
+	RstObjectTypes map[string][]string
+	// Enums are the values of each enum type that directive arguments and options can have.
+	Enums map[string][]string
 }
 
-// RolesMap contains roles from rstspec.toml
-type RolesMap map[string]string
+// RolesMap contains every role from rstspec.toml, with its type
+type RolesMap map[string]RoleSpec
 
 // OtherRoleMap contains other roles from rstspec.toml, like guilabel
 type OtherRoleMap map[string]string
@@ -46,52 +53,45 @@ func NewRoleMap(input []byte) *RstSpec {
 }
 
 func (r *RstSpec) populateRoles(raw *RawRstSpec) {
-	r.RawRoles = make(map[string]bool, len(raw.Roles))
-	for k := range raw.Roles {
-		r.RawRoles[k] = true
-	}
-
-	// filter out roles that aren't links, and convert to a RoleMap
+	r.Roles = make(RolesMap, len(raw.Roles))
 	for k, v := range raw.Roles {
-		switch (v.(map[string]interface{})["type"]).(type) {
-		case map[string]interface{}:
-			continue
-		default:
-			delete(raw.Roles, k)
-		}
-	}
-	for k, v := range raw.Roles {
-		urlObj := v.(map[string]interface{})["type"]
-		target := urlObj.(map[string]interface{})["link"]
-		raw.Roles[k] = target
-	}
-	roleMap := make(RolesMap, len(raw.Roles))
-	for k, v := range raw.Roles {
-		if v != nil {
-			roleMap[k] = v.(string)
-		}
+		r.Roles[k] = roleSpec(v)
 	}
-	r.Roles = roleMap
-
 }
 
 func (r *RstSpec) populateDirectives(raw *RawRstSpec) {
-	r.Directives = make(map[string]bool, len(raw.Directives))
-
+	r.Directives = make(map[string]DirectiveSpec, len(raw.Directives))
 	for k := range raw.Directives {
-		r.Directives[k] = true
+		r.Directives[k] = directiveSpec(k, raw.Directives, make(map[string]bool))
+	}
+
+	r.Enums = make(map[string][]string, len(raw.Enums))
+	for k, v := range raw.Enums {
+		values, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		r.Enums[k] = make([]string, 0, len(values))
+		for _, value := range values {
+			r.Enums[k] = append(r.Enums[k], fmt.Sprint(value))
+		}
 	}
 }
 
 func (r *RstSpec) populateRstObjects(raw *RawRstSpec) {
 	r.RstObjects = make(map[string]bool, len(raw.RstObjects))
+	r.RstObjectTypes = make(map[string][]string, len(raw.RstObjects))
 
 	for k := range raw.RstObjects {
 		target := strings.Split(k, ":")
+		name := k
 		if len(target) > 1 {
-			r.RstObjects[target[1]] = true
-		} else {
-			r.RstObjects[k] = true
+			name = target[1]
 		}
+		r.RstObjects[name] = true
+		r.RstObjectTypes[name] = append(r.RstObjectTypes[name], k)
+	}
+	for _, types := range r.RstObjectTypes {
+		sort.Strings(types)
 	}
 }
@@ -73,10 +73,24 @@ func TestRoleMap(t *testing.T) {
 	roleMap := NewRoleMap([]byte(rstSpec))
 
 	expected := &RstSpec{
-		Roles:      map[string]string{"rfc": "https://tools.ietf.org/html/%s", "wikipedia": "https://en.wikipedia.org/wiki/%s"},
-		RawRoles:   map[string]bool{"abbr": true, "file": true, "icon-fa4": true, "rfc": true, "wikipedia": true},
-		Directives: map[string]bool{"div": true, "container": true, "default-domain": true},
+		Roles: RolesMap{
+			"abbr":      {Type: RoleText},
+			"file":      {Type: RoleText},
+			"icon-fa4":  {Type: RoleExplicitTitle},
+			"rfc":       {Type: RoleLink, Link: "https://tools.ietf.org/html/%s"},
+			"wikipedia": {Type: RoleLink, Link: "https://en.wikipedia.org/wiki/%s"},
+		},
+		Directives: map[string]DirectiveSpec{
+			"div":            {ArgumentType: ArgumentType{"string"}, Options: map[string]ArgumentType{}, Deprecated: true},
+			"container":      {ArgumentType: ArgumentType{"string"}, Options: map[string]ArgumentType{}, Deprecated: true},
+			"default-domain": {ArgumentType: ArgumentType{"string"}, Options: map[string]ArgumentType{}},
+		},
 		RstObjects: map[string]bool{"class": true, "meth": true, "func": true, "projection": true, "method": true, "authrole": true, "authaction": true},
+		RstObjectTypes: map[string][]string{
+			"class": {"py:class"}, "meth": {"py:meth"}, "func": {"js:func"}, "projection": {"mongodb:projection"},
+			"method": {"mongodb:method"}, "authrole": {"mongodb:authrole"}, "authaction": {"mongodb:authaction"},
+		},
+		Enums: map[string][]string{},
 	}
 
 	assert.EqualValues(t, expected, roleMap)
@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// KindUnknownRole marks roles that rstspec.toml doesn't define.
+	KindUnknownRole = "unknown-role"
+	// KindBadRoleTarget marks role targets that can't be what the role's type expects.
+	KindBadRoleTarget = "bad-role-target"
+)
+
+// The types of role in rstspec.toml. A role's type is either the name of one of the primitive types, or a
+// table with a link template, or with the domain and name of the objects the role refers to.
+const (
+	RoleText          = "text"
+	RoleExplicitTitle = "explicit_title"
+	RoleLink          = "link"
+	RoleRef           = "ref_role"
+)
+
+// RoleSpec is what rstspec.toml says about a role.
+type RoleSpec struct {
+	// Type is one of RoleText, RoleExplicitTitle, RoleLink or RoleRef.
+	Type string
+	// Link is the url template of a link role, with %s standing for the target.
+	Link string
+	// EnsureTrailingSlash adds a / to the targets of a link role that don't end with one.
+	EnsureTrailingSlash bool
+	// Format lists how the role's text is formatted, such as monospace.
+	Format []string
+	// Domain, Name and Tag identify the objects a ref role refers to, such as the std domain's labels.
+	Domain string
+	Name   string
+	Tag    string
+}
+
+// URL builds the url a link role refers to the way the Snooty parser does: a trailing slash is added to
+// target if the role ensures one, and it replaces the %s of the template. Snooty only accepts templates
+// with exactly one %s, so false is returned for other templates, as it is for roles that aren't links.
+func (s RoleSpec) URL(target string) (string, bool) {
+	if s.Type != RoleLink || strings.Count(s.Link, "%s") != 1 {
+		return "", false
+	}
+	target = strings.TrimSpace(target)
+	if s.EnsureTrailingSlash && !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	return strings.Replace(s.Link, "%s", target, 1), true
+}
+
+// CheckTarget returns an error describing why target can't be a target of the role: every role needs
+// one, an explicit title must be followed by its <target>, and the target of a link role is part of a
+// url, so it can't have spaces or be a url of its own.
+func (s RoleSpec) CheckTarget(target string) error {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return fmt.Errorf("has no target")
+	}
+	if s.Type == RoleText {
+		return nil
+	}
+	if strings.Contains(target, "<") && !strings.HasSuffix(target, ">") {
+		return fmt.Errorf("has a title without a closing > after its target")
+	}
+	if s.Type == RoleLink {
+		if strings.ContainsAny(target, " \t\n") {
+			return fmt.Errorf("has whitespace in its target, which is part of the url %s", s.Link)
+		}
+		if strings.Contains(target, "://") {
+			return fmt.Errorf("has a url as its target, but only the part that replaces %%s in %s", s.Link)
+		}
+	}
+	return nil
+}
+
+// Role returns the spec of the role name. A role of a domain, such as mongodb:method, may be used
+// without its domain.
+func (r *RstSpec) Role(name string) (RoleSpec, bool) {
+	if spec, ok := r.Roles[name]; ok {
+		return spec, true
+	}
+	if strings.Contains(name, ":") {
+		return RoleSpec{}, false
+	}
+	keys := make([]string, 0)
+	for key := range r.Roles {
+		if strings.HasSuffix(key, ":"+name) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return RoleSpec{}, false
+	}
+	sort.Strings(keys)
+	return r.Roles[keys[0]], true
+}
+
+// roleSpec reads the spec of a role from its raw table in rstspec.toml.
+func roleSpec(raw interface{}) RoleSpec {
+	fields, _ := raw.(map[string]interface{})
+	switch t := fields["type"].(type) {
+	case string:
+		return RoleSpec{Type: t}
+	case map[string]interface{}:
+		spec := RoleSpec{}
+		spec.Link, _ = t["link"].(string)
+		spec.EnsureTrailingSlash, _ = t["ensure_trailing_slash"].(bool)
+		spec.Domain, _ = t["domain"].(string)
+		spec.Name, _ = t["name"].(string)
+		spec.Tag, _ = t["tag"].(string)
+		if format, ok := t["format"].([]interface{}); ok {
+			for _, f := range format {
+				spec.Format = append(spec.Format, fmt.Sprint(f))
+			}
+		}
+		spec.Type = RoleRef
+		if spec.Link != "" {
+			spec.Type = RoleLink
+		}
+		return spec
+	}
+	return RoleSpec{Type: RoleText}
+}
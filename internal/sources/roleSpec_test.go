@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const roleRstSpec = `
+[role.manual]
+type = {link = "https://www.mongodb.com/docs/manual%s", ensure_trailing_slash = true}
+
+[role.github]
+type = {link = "https://github.com/%s", format = ["monospace"]}
+
+[role.atlas]
+type = {link = "https://www.mongodb.com/docs/atlas/"}
+
+[role.ref]
+type = {domain = "std", name = "label", tag = "ref"}
+
+[role."mongodb:method"]
+type = {domain = "mongodb", name = "method"}
+
+[role.abbr]
+type = "text"
+
+[role.icon]
+type = "explicit_title"
+`
+
+func TestRoleSpec(t *testing.T) {
+	spec := NewRoleMap([]byte(roleRstSpec))
+
+	assert.Equal(t, RoleSpec{Type: RoleLink, Link: "https://github.com/%s", Format: []string{"monospace"}}, spec.Roles["github"])
+	assert.Equal(t, RoleSpec{Type: RoleRef, Domain: "std", Name: "label", Tag: "ref"}, spec.Roles["ref"])
+	method, ok := spec.Role("method")
+	assert.True(t, ok)
+	assert.Equal(t, RoleSpec{Type: RoleRef, Domain: "mongodb", Name: "method"}, method)
+	_, ok = spec.Role("nope")
+	assert.False(t, ok)
+}
+
+func TestRoleURL(t *testing.T) {
+	spec := NewRoleMap([]byte(roleRstSpec))
+	cases := []struct {
+		role     string
+		target   string
+		expected string
+		ok       bool
+	}{
+		{"manual", "/reference/method/db.collection.find", "https://www.mongodb.com/docs/manual/reference/method/db.collection.find/", true},
+		{"manual", "/reference/", "https://www.mongodb.com/docs/manual/reference/", true},
+		{"github", "mongodb/snooty%20parser", "https://github.com/mongodb/snooty%20parser", true},
+		{"atlas", "clusters", "", false},
+		{"ref", "install", "", false},
+		{"abbr", "TLS", "", false},
+	}
+
+	for _, test := range cases {
+		url, ok := spec.Roles[test.role].URL(test.target)
+		assert.Equal(t, test.expected, url, ":%s:`%s`", test.role, test.target)
+		assert.Equal(t, test.ok, ok, ":%s:`%s`", test.role, test.target)
+	}
+}
+
+func TestRoleCheckTarget(t *testing.T) {
+	spec := NewRoleMap([]byte(roleRstSpec))
+	cases := []struct {
+		role   string
+		target string
+		err    string
+	}{
+		{"github", "mongodb/snooty-parser", ""},
+		{"github", " ", "has no target"},
+		{"github", "mongodb/snooty parser", "has whitespace in its target, which is part of the url https://github.com/%s"},
+		{"github", "https://github.com/mongodb", "has a url as its target, but only the part that replaces %s in https://github.com/%s"},
+		{"icon", "Star <fa-star", "has a title without a closing > after its target"},
+		{"ref", "Install the driver", ""},
+		{"abbr", "TLS (Transport Layer <Security)", ""},
+	}
+
+	for _, test := range cases {
+		err := spec.Roles[test.role].CheckTarget(test.target)
+		if test.err == "" {
+			assert.NoError(t, err, ":%s:`%s`", test.role, test.target)
+		} else {
+			assert.EqualError(t, err, test.err, ":%s:`%s`", test.role, test.target)
+		}
+	}
+}
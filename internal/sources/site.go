@@ -0,0 +1,53 @@
+package sources
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// KindOwnSite marks absolute links to pages of the docset itself, which should be :doc: or :ref: roles.
+const KindOwnSite = "own-site"
+
+// siteBase is where MongoDB docsets are published, under their snooty.toml name.
+const siteBase = "https://www.mongodb.com/docs/"
+
+var projectNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// SiteURL returns the url the docset is published at: BaseURL if it is set, or else the docs site's url
+// for the project's snooty.toml name, if that is a plain name such as "compass".
+func (cfg *CheckerConfig) SiteURL(project *TomlConfig) string {
+	if cfg != nil && cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	if project != nil && projectNameRegex.MatchString(project.Name) {
+		return siteBase + project.Name + "/"
+	}
+	return ""
+}
+
+// SitePath returns the path of link below the site url base, without slashes at either end, and its
+// fragment. It reports false if link isn't a page on the site, or has a query string. The scheme of link
+// may be http or https either way.
+func SitePath(link, base string) (string, string, bool) {
+	if base == "" {
+		return "", "", false
+	}
+	l, err := url.Parse(link)
+	if err != nil || (l.Scheme != "http" && l.Scheme != "https") || l.RawQuery != "" {
+		return "", "", false
+	}
+	b, err := url.Parse(base)
+	if err != nil || !strings.EqualFold(l.Host, b.Host) {
+		return "", "", false
+	}
+	prefix := strings.TrimSuffix(b.Path, "/") + "/"
+	path := l.Path
+	if path+"/" == prefix {
+		path = prefix
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return "", "", false
+	}
+	return strings.Trim(strings.TrimPrefix(path, prefix), "/"), l.Fragment, true
+}
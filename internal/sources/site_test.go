@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteURL(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(`{"base_url": "https://www.mongodb.com/docs/compass/current/"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://www.mongodb.com/docs/compass/current/", cfg.SiteURL(&TomlConfig{Name: "compass"}))
+
+	var none *CheckerConfig
+	assert.Equal(t, "https://www.mongodb.com/docs/compass/", none.SiteURL(&TomlConfig{Name: "compass"}))
+	assert.Equal(t, "", none.SiteURL(&TomlConfig{Name: "this is a test"}))
+	assert.Equal(t, "", none.SiteURL(nil))
+}
+
+func TestSitePath(t *testing.T) {
+	base := "https://www.mongodb.com/docs/compass/current/"
+	cases := []struct {
+		link     string
+		path     string
+		fragment string
+		ok       bool
+	}{{
+		link: "https://www.mongodb.com/docs/compass/current/",
+		path: "",
+		ok:   true,
+	}, {
+		link: "https://www.mongodb.com/docs/compass/current",
+		path: "",
+		ok:   true,
+	}, {
+		link: "http://WWW.mongodb.com/docs/compass/current/query/filter/",
+		path: "query/filter",
+		ok:   true,
+	}, {
+		link:     "https://www.mongodb.com/docs/compass/current/install#std-label-install-linux",
+		path:     "install",
+		fragment: "std-label-install-linux",
+		ok:       true,
+	}, {
+		link: "https://www.mongodb.com/docs/compass/current/search?q=x",
+	}, {
+		link: "https://www.mongodb.com/docs/compass/master/install/",
+	}, {
+		link: "https://www.mongodb.com/docs/compass/currently/",
+	}, {
+		link: "ftp://www.mongodb.com/docs/compass/current/",
+	}}
+	for _, c := range cases {
+		path, fragment, ok := SitePath(c.link, base)
+		assert.Equal(t, c.ok, ok, c.link)
+		assert.Equal(t, c.path, path, c.link)
+		assert.Equal(t, c.fragment, fragment, c.link)
+	}
+	_, _, ok := SitePath("https://www.mongodb.com/docs/compass/current/", "")
+	assert.False(t, ok)
+}
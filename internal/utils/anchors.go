@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// HasAnchor reports whether the html page body has an element that the fragment anchor links to: one
+// with an id, or an a with a name, of that value. anchor may be percent-encoded, as it is in urls.
+func HasAnchor(body []byte, anchor string) bool {
+	if decoded, err := url.PathUnescape(anchor); err == nil {
+		anchor = decoded
+	}
+	if anchor == "" {
+		return true
+	}
+	quoted := regexp.QuoteMeta(anchor)
+	// attribute names are case-insensitive in html, but ids are not
+	attr := regexp.MustCompile(`\s(?i:id|name)\s*=\s*(?:"` + quoted + `"|'` + quoted + `'|` + quoted + `[\s/>])`)
+	return attr.Match(body)
+}
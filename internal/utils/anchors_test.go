@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasAnchor(t *testing.T) {
+	body := []byte(`<html><body>
+<section id="getting-started"><h2>Getting Started</h2></section>
+<dl class="py method"><dt class="sig sig-object py" id='pymongo.collection.Collection.find'></dt></dl>
+<a NAME=legacy-anchor></a>
+<p>See getting-help for more.</p>
+</body></html>`)
+
+	cases := []struct {
+		anchor string
+		found  bool
+	}{
+		{"getting-started", true},
+		{"pymongo.collection.Collection.find", true},
+		{"legacy-anchor", true},
+		{"", true},
+		{"getting%2Dstarted", true},
+		{"getting-help", false},
+		{"Getting-Started", false},
+		{"getting", false},
+		{"pymongo.collection.Collection.find_one", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.found, HasAnchor(body, c.anchor), c.anchor)
+	}
+}
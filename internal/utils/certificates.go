@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// Kinds of warnings about the certificates served by linked hosts.
+const (
+	KindCertExpiring         = "cert-expiring"
+	KindWeakCertificate      = "weak-certificate"
+	KindHostnameMismatch     = "hostname-mismatch"
+	KindUntrustedCertificate = "untrusted-certificate"
+)
+
+const (
+	minRSABits   = 2048
+	minECDSABits = 256
+)
+
+var weakSignatures = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.DSAWithSHA256: true,
+	x509.ECDSAWithSHA1: true,
+}
+
+// Certificate is what checker keeps of one certificate in a served chain.
+type Certificate struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	NotAfter           time.Time `json:"not_after"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	WeakSignature      bool      `json:"weak_signature,omitempty"`
+	KeyAlgorithm       string    `json:"key_algorithm"`
+	KeyBits            int       `json:"key_bits,omitempty"`
+	SelfSigned         bool      `json:"self_signed,omitempty"`
+}
+
+// CertificateReport describes the chain a host served during Preflight, leaf first, along with why the
+// chain couldn't be verified or isn't valid for the host, if it isn't.
+type CertificateReport struct {
+	Host          string        `json:"host"`
+	Chain         []Certificate `json:"chain"`
+	VerifyError   string        `json:"verify_error,omitempty"`
+	HostnameError string        `json:"hostname_error,omitempty"`
+}
+
+// CertificateProblem is one thing wrong with a CertificateReport. Kind is one of the certificate kinds.
+type CertificateProblem struct {
+	Kind    string
+	Message string
+}
+
+func newCertificateReport(host string, chain []*x509.Certificate) *CertificateReport {
+	report := &CertificateReport{Host: host, Chain: make([]Certificate, 0, len(chain))}
+	for _, cert := range chain {
+		algorithm, bits := publicKey(cert)
+		report.Chain = append(report.Chain, Certificate{
+			Subject:            cert.Subject.String(),
+			Issuer:             cert.Issuer.String(),
+			NotAfter:           cert.NotAfter,
+			SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+			WeakSignature:      weakSignatures[cert.SignatureAlgorithm],
+			KeyAlgorithm:       algorithm,
+			KeyBits:            bits,
+			SelfSigned:         cert.Subject.String() == cert.Issuer.String() && cert.CheckSignatureFrom(cert) == nil,
+		})
+	}
+	return report
+}
+
+func publicKey(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case *dsa.PublicKey:
+		return "DSA", key.P.BitLen()
+	case ed25519.PublicKey:
+		return "Ed25519", 0
+	}
+	return cert.PublicKeyAlgorithm.String(), 0
+}
+
+// Problems lists what is wrong with the chain as of now: certificates that expire within expiryDays,
+// weak signatures or keys, a chain that can't be verified, and a leaf that isn't valid for the host. The
+// signature on a self-signed certificate is never checked by clients, so it doesn't count as weak.
+func (r *CertificateReport) Problems(now time.Time, expiryDays int) []CertificateProblem {
+	problems := make([]CertificateProblem, 0)
+	if r == nil {
+		return problems
+	}
+	for _, cert := range r.Chain {
+		if now.After(cert.NotAfter) {
+			problems = append(problems, CertificateProblem{KindCertExpiring, fmt.Sprintf("certificate for %s expired on %s", cert.Subject, cert.NotAfter.Format("2006-01-02"))})
+		} else if days := int(cert.NotAfter.Sub(now).Hours() / 24); days < expiryDays {
+			problems = append(problems, CertificateProblem{KindCertExpiring, fmt.Sprintf("certificate for %s expires in %d days, on %s", cert.Subject, days, cert.NotAfter.Format("2006-01-02"))})
+		}
+		if cert.WeakSignature && !cert.SelfSigned {
+			problems = append(problems, CertificateProblem{KindWeakCertificate, fmt.Sprintf("certificate for %s is signed with %s", cert.Subject, cert.SignatureAlgorithm)})
+		}
+		if weakKey(cert) {
+			problems = append(problems, CertificateProblem{KindWeakCertificate, fmt.Sprintf("certificate for %s uses a weak key (%s)", cert.Subject, cert.Key())})
+		}
+	}
+	if r.VerifyError != "" {
+		problems = append(problems, CertificateProblem{KindUntrustedCertificate, r.VerifyError})
+	}
+	if r.HostnameError != "" {
+		problems = append(problems, CertificateProblem{KindHostnameMismatch, r.HostnameError})
+	}
+	return problems
+}
+
+func weakKey(cert Certificate) bool {
+	switch cert.KeyAlgorithm {
+	case "RSA":
+		return cert.KeyBits < minRSABits
+	case "ECDSA":
+		return cert.KeyBits < minECDSABits
+	case "DSA":
+		return true
+	}
+	return false
+}
+
+// Key describes the certificate's public key, such as "RSA 2048".
+func (c Certificate) Key() string {
+	if c.KeyBits == 0 {
+		return c.KeyAlgorithm
+	}
+	return fmt.Sprintf("%s %d", c.KeyAlgorithm, c.KeyBits)
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tlsServer starts a server whose self-signed certificate is valid for names until notAfter, and a checker
+// that trusts it.
+func tlsServer(t *testing.T, names []string, notAfter time.Time) (*httptest.Server, *HTTPChecker) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "checker test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		DNSNames:              names,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	checker := NewHTTPChecker(nil)
+	checker.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	return srv, checker
+}
+
+func TestPreflightCertificate(t *testing.T) {
+	srv, checker := tlsServer(t, nil, time.Now().Add(10*24*time.Hour))
+	defer srv.Close()
+
+	cert, err := checker.Preflight(context.Background(), srv.URL)
+	assert.NoError(t, err, "a certificate for another name is a problem with the certificate, not the host")
+	if assert.NotNil(t, cert) {
+		assert.Equal(t, "127.0.0.1", cert.Host)
+		assert.Len(t, cert.Chain, 1)
+		assert.Equal(t, "CN=checker test", cert.Chain[0].Subject)
+		assert.Equal(t, "ECDSA 256", cert.Chain[0].Key())
+		assert.True(t, cert.Chain[0].SelfSigned)
+
+		kinds := make([]string, 0)
+		for _, problem := range cert.Problems(time.Now(), 30) {
+			kinds = append(kinds, problem.Kind)
+		}
+		assert.Equal(t, []string{KindCertExpiring, KindHostnameMismatch}, kinds)
+		assert.Len(t, cert.Problems(time.Now(), 5), 1, "the certificate isn't expiring within 5 days")
+	}
+}
+
+func TestPreflightHealthyCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	checker := NewHTTPChecker(nil)
+	checker.Client = srv.Client()
+
+	cert, err := checker.Preflight(context.Background(), srv.URL)
+	assert.NoError(t, err)
+	if assert.NotNil(t, cert) {
+		assert.Empty(t, cert.Problems(time.Now(), 30))
+		assert.Equal(t, KindCertExpiring, cert.Problems(time.Now(), 1000000)[0].Kind)
+	}
+
+	cert, err = NewHTTPChecker(nil).Preflight(context.Background(), srv.URL)
+	assert.NoError(t, err, "an untrusted certificate is a problem with the certificate, not the host")
+	if assert.NotNil(t, cert, "the report is kept when verification fails") {
+		assert.Equal(t, "TLS certificate is signed by an unknown authority", cert.VerifyError)
+	}
+}
+
+func TestCertificateProblems(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := &CertificateReport{Host: "old.example.com", Chain: []Certificate{{
+		Subject:            "CN=old.example.com",
+		NotAfter:           now.Add(-24 * time.Hour),
+		SignatureAlgorithm: "SHA1-RSA",
+		WeakSignature:      true,
+		KeyAlgorithm:       "RSA",
+		KeyBits:            1024,
+	}, {
+		Subject:            "CN=Old Root",
+		NotAfter:           now.Add(365 * 24 * time.Hour),
+		SignatureAlgorithm: "SHA1-RSA",
+		WeakSignature:      true,
+		KeyAlgorithm:       "RSA",
+		KeyBits:            4096,
+		SelfSigned:         true,
+	}}}
+
+	messages := make([]string, 0)
+	for _, problem := range report.Problems(now, 30) {
+		messages = append(messages, problem.Kind+": "+problem.Message)
+	}
+	assert.Equal(t, []string{
+		"cert-expiring: certificate for CN=old.example.com expired on 2023-12-31",
+		"weak-certificate: certificate for CN=old.example.com is signed with SHA1-RSA",
+		"weak-certificate: certificate for CN=old.example.com uses a weak key (RSA 1024)",
+	}, messages)
+
+	var none *CertificateReport
+	assert.Empty(t, none.Problems(now, 30))
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MongoCaleb/checker/internal/sources"
+	log "github.com/sirupsen/logrus"
+)
+
+// LinkChecker checks urls and fetches network files. HTTPChecker talks to the network, ReplayChecker
+// answers from a recording made with a Recorder.
+type LinkChecker interface {
+	// Check reports whether uri is reachable, following and recording any redirects.
+	Check(ctx context.Context, uri string) (HttpResponse, bool)
+	// Fetch follows redirects and returns the body of a successful response.
+	Fetch(ctx context.Context, uri string) ([]byte, bool)
+	// Preflight returns an error if the host serving uri can't be reached at all, and a report on the
+	// certificate it serves if it uses TLS.
+	Preflight(ctx context.Context, uri string) (*CertificateReport, error)
+}
+
+// defaultRequestTimeout limits requests to hosts whose settings don't set a timeout.
+var defaultRequestTimeout = 30 * time.Second
+
+// HTTPChecker checks urls over HTTP, customizing each request with the host settings in Config.
+type HTTPChecker struct {
+	Client *http.Client
+	Config *sources.CheckerConfig
+
+	// unset holds the headers that have been warned about for using an unset environment variable
+	unset sync.Map
+}
+
+func NewHTTPChecker(cfg *sources.CheckerConfig) *HTTPChecker {
+	return &HTTPChecker{Client: client, Config: cfg}
+}
+
+// Check treats a 200, or any status the host settings accept, as reachable. Accepted non-200 statuses
+// are marked as Accepted. Failures that aren't an HTTP status get a Kind from NetworkKinds and an
+// explanation in Message.
+func (c *HTTPChecker) Check(ctx context.Context, uri string) (HttpResponse, bool) {
+	// check to see if there's a way to avoid triggering page viewws
+	// block add blockers
+	// test net.DialTCP
+	// look at muffet to see what they do to make sure a url is valid
+
+	var r HttpResponse
+
+	seen := map[string]bool{uri: true}
+	current := uri
+	for {
+		settings := c.Config.HostSettingsFor(current)
+		response, _, err := c.do(ctx, current, settings, false)
+		if err != nil {
+			r.Code = 0
+			r.Kind, r.Message = ClassifyError(err)
+			return r, false
+		}
+		r.Header = response.Header
+
+		if settings.Accepts(response.StatusCode) {
+			r.Code = response.StatusCode
+			r.Accepted = response.StatusCode != 200
+			return r, true
+		}
+
+		next, err := response.Location()
+		if !redirects.contains(response.StatusCode) || err != nil {
+			r.Code = response.StatusCode
+			if response.StatusCode == 200 {
+				return r, true
+			}
+			r.Message = response.Request.URL.Path
+			return r, false
+		}
+
+		r.Redirects = append(r.Redirects, Redirect{Code: response.StatusCode, From: current, To: next.String()})
+		if seen[next.String()] {
+			r.Code = response.StatusCode
+			r.Kind = KindRedirectLoop
+			r.Message = "redirect loop: " + r.Chain()
+			return r, false
+		}
+		if len(r.Redirects) >= maxRedirects {
+			r.Code = response.StatusCode
+			r.Kind = KindTooManyRedirects
+			r.Message = fmt.Sprintf("stopped after %d redirects: %s", maxRedirects, r.Chain())
+			return r, false
+		}
+		seen[next.String()] = true
+		current = next.String()
+	}
+}
+
+func (c *HTTPChecker) Fetch(ctx context.Context, uri string) ([]byte, bool) {
+	current := uri
+	for i := 0; i <= maxRedirects; i++ {
+		response, body, err := c.do(ctx, current, c.Config.HostSettingsFor(current), true)
+		if err != nil {
+			return nil, false
+		}
+		if next, err := response.Location(); err == nil && redirects.contains(response.StatusCode) {
+			current = next.String()
+			continue
+		}
+		return body, response.StatusCode == 200
+	}
+	return nil, false
+}
+
+// do makes a single request for uri without following redirects. The body is only read when withBody
+// is set, which also forces a GET.
+func (c *HTTPChecker) do(ctx context.Context, uri string, settings *sources.HostSettings, withBody bool) (*http.Response, []byte, error) {
+	method := "GET"
+	if settings != nil && settings.Method != "" && !withBody {
+		method = settings.Method
+	}
+	timeout := settings.RequestTimeout()
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, uri, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Connection", "Keep-Alive")
+	req.Header.Set("Accept-Language", "en-US")
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	if settings != nil {
+		if settings.UserAgent != "" {
+			req.Header.Set("User-Agent", settings.UserAgent)
+		}
+		for k, v := range settings.Headers {
+			req.Header.Set(k, c.expandHeader(settings, k, v))
+		}
+	}
+
+	response, err := c.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	var body []byte
+	if withBody {
+		if body, err = ioutil.ReadAll(response.Body); err != nil {
+			return nil, nil, err
+		}
+	}
+	response.Body = http.NoBody
+	return response, body, nil
+}
+
+// expandHeader expands the environment variables in the value of the header name that settings configure.
+// A variable that isn't set expands to nothing, as with a shell, but is warned about once for each header,
+// since the credential it holds is then missing and the host is likely to refuse the request.
+func (c *HTTPChecker) expandHeader(settings *sources.HostSettings, name, value string) string {
+	return os.Expand(value, func(variable string) string {
+		v, ok := os.LookupEnv(variable)
+		if !ok {
+			hosts := settings.Host
+			if hosts == "" {
+				hosts = settings.Pattern
+			}
+			if _, warned := c.unset.LoadOrStore(hosts+" "+name+" "+variable, true); !warned {
+				log.Warnf("The %s header for %s uses $%s, which isn't set, so it is sent without it", name, hosts, variable)
+			}
+		}
+		return v
+	})
+}
+
+// UpgradesToHTTPS reports whether the https version of an http uri serves the same content, either
+// because the http uri redirects to it or because both return identical successful responses.
+func UpgradesToHTTPS(ctx context.Context, checker LinkChecker, uri string) bool {
+	if !strings.HasPrefix(uri, "http://") {
+		return false
+	}
+	secure := "https://" + strings.TrimPrefix(uri, "http://")
+	if resp, ok := checker.Check(ctx, uri); ok && resp.FinalURL() == secure {
+		return true
+	}
+	return sameContent(ctx, checker, uri, secure)
+}
+
+func sameContent(ctx context.Context, checker LinkChecker, a, b string) bool {
+	bodyA, ok := checker.Fetch(ctx, a)
+	if !ok {
+		return false
+	}
+	bodyB, ok := checker.Fetch(ctx, b)
+	if !ok {
+		return false
+	}
+	return bytes.Equal(bodyA, bodyB)
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/MongoCaleb/checker/internal/sources"
+)
+
+const (
+	// KindPrivateHost marks links to localhost, loopback, private or link-local addresses.
+	KindPrivateHost = "private-host"
+	// KindInternalHost marks links to hosts matching the configured internal_hosts.
+	KindInternalHost = "internal-host"
+	// KindCredentials marks links with a password in their userinfo.
+	KindCredentials = "credentials"
+	// KindSecretParam marks links with a token, key or password in their query string.
+	KindSecretParam = "secret-param"
+)
+
+// LeakKinds are the kinds of Leak.
+var LeakKinds = []string{KindPrivateHost, KindInternalHost, KindCredentials, KindSecretParam}
+
+// secretParams are query parameters whose values are credentials. Names ending in _token, _secret,
+// _password or _key are secret too.
+var secretParams = map[string]bool{
+	"token": true, "access_token": true, "apikey": true, "api_key": true, "password": true, "passwd": true,
+	"pwd": true, "secret": true, "client_secret": true, "sig": true, "signature": true, "auth": true,
+	"x-amz-signature": true, "x-amz-credential": true, "x-amz-security-token": true, "x-goog-signature": true,
+}
+
+var secretSuffixes = []string{"_token", "_secret", "_password", "_key", "-token", "-key"}
+
+// redacted replaces secrets in reports.
+const redacted = "xxxxx"
+
+// Leak is something in a link that shouldn't be published. Kind is one of LeakKinds.
+type Leak struct {
+	Kind    string
+	Message string
+}
+
+// FindLeaks lists what uri gives away: a host readers can't reach because it is private or internal,
+// a password in its userinfo, or secret-looking query parameters. Parameters whose values are
+// placeholders, such as YOUR_API_KEY, are not secrets.
+func FindLeaks(uri string, cfg *sources.CheckerConfig) []Leak {
+	leaks := make([]Leak, 0)
+	u, err := url.Parse(uri)
+	if err != nil {
+		return leaks
+	}
+
+	if host := u.Hostname(); host != "" {
+		if why, ok := privateHost(host); ok {
+			leaks = append(leaks, Leak{KindPrivateHost, fmt.Sprintf("links to %s, which readers can't reach", why)})
+		} else if cfg.IsInternalHost(host) {
+			leaks = append(leaks, Leak{KindInternalHost, fmt.Sprintf("links to the internal host %s", host)})
+		}
+	}
+	if _, ok := u.User.Password(); ok {
+		leaks = append(leaks, Leak{KindCredentials, fmt.Sprintf("contains the password of %s", u.User.Username())})
+	}
+	for _, name := range secretQueryParams(u, cfg) {
+		leaks = append(leaks, Leak{KindSecretParam, fmt.Sprintf("the %s parameter looks like a secret", name)})
+	}
+	return leaks
+}
+
+// Redact replaces the password and any secret query parameter values in uri, so that uri can be
+// reported without repeating the leak.
+func Redact(uri string, cfg *sources.CheckerConfig) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	secrets := secretQueryParams(u, cfg)
+	if len(secrets) == 0 {
+		return u.String()
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil && containsFold(secrets, unescaped) {
+			params[i] = name + "=" + redacted
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+	return u.String()
+}
+
+// privateHost describes host if it is localhost, or a loopback, private, link-local or unspecified address.
+func privateHost(host string) (string, bool) {
+	lower := strings.ToLower(host)
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return "localhost", true
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return "", false
+	case ip.IsLoopback():
+		return fmt.Sprintf("the loopback address %s", host), true
+	case ip.IsPrivate():
+		return fmt.Sprintf("the private address %s", host), true
+	case ip.IsLinkLocalUnicast():
+		return fmt.Sprintf("the link-local address %s", host), true
+	case ip.IsUnspecified():
+		return fmt.Sprintf("the unspecified address %s", host), true
+	}
+	return "", false
+}
+
+// secretQueryParams returns the names of the query parameters of u that hold secrets.
+func secretQueryParams(u *url.URL, cfg *sources.CheckerConfig) []string {
+	names := make([]string, 0)
+	for name, values := range u.Query() {
+		if !isSecretParam(name, cfg) {
+			continue
+		}
+		for _, value := range values {
+			if !placeholder(value) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isSecretParam(name string, cfg *sources.CheckerConfig) bool {
+	lower := strings.ToLower(name)
+	if secretParams[lower] || cfg.IsSecretParam(lower) {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder reports whether value is empty or stands in for a secret rather than being one: written
+// in capitals such as YOUR_TOKEN, or a single repeated character such as xxxx.
+func placeholder(value string) bool {
+	if value == "" || strings.Trim(value, string([]rune(value)[0])) == "" {
+		return true
+	}
+	for _, r := range value {
+		if !('A' <= r && r <= 'Z') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s []string, e string) bool {
+	for _, a := range s {
+		if strings.EqualFold(a, e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Kinds of diagnostics for urls that never produced a usable response. Each one is a class of failure
+// that can be given its own severity in the checker config.
+const (
+	KindDNS               = "dns-error"
+	KindConnectionRefused = "connection-refused"
+	KindConnectionReset   = "connection-reset"
+	KindTLS               = "tls-error"
+	KindTimeout           = "timeout"
+	KindRedirectLoop      = "redirect-loop"
+	KindTooManyRedirects  = "too-many-redirects"
+	KindNotRecorded       = "not-recorded"
+	KindNetwork           = "network-error"
+)
+
+// NetworkKinds lists every class ClassifyError and HTTPChecker.Check can report.
+var NetworkKinds = []string{
+	KindDNS, KindConnectionRefused, KindConnectionReset, KindTLS, KindTimeout,
+	KindRedirectLoop, KindTooManyRedirects, KindNotRecorded, KindNetwork, KindInvalidURI,
+}
+
+// ClassifiedError is an error whose class is already known, such as a failure read back from a replay file.
+type ClassifiedError struct {
+	Kind string
+	Err  error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError works out why a request failed and returns the class along with an explanation that
+// makes sense to someone who isn't looking at the Go error.
+func ClassifyError(err error) (string, string) {
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Kind, classified.Err.Error()
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Op == "parse" {
+		// the url is malformed, so no request was made
+		return KindInvalidURI, urlErr.Err.Error()
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			return KindDNS, fmt.Sprintf("the domain %s does not exist (NXDOMAIN)", dnsErr.Name)
+		}
+		if dnsErr.IsTimeout {
+			return KindDNS, fmt.Sprintf("DNS lookup for %s timed out", dnsErr.Name)
+		}
+		return KindDNS, fmt.Sprintf("DNS lookup for %s failed: %s", dnsErr.Name, dnsErr.Err)
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return KindTLS, "TLS certificate is signed by an unknown authority"
+	case errors.As(err, &hostname):
+		return KindTLS, "TLS certificate " + strings.TrimPrefix(hostname.Error(), "x509: certificate ")
+	case errors.As(err, &invalid):
+		return KindTLS, certificateInvalidReason(invalid)
+	case errors.As(err, &recordHeader):
+		return KindTLS, "the server did not answer with TLS"
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindConnectionRefused, "the connection was refused, nothing is listening on that port"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return KindConnectionReset, "the server closed the connection without responding"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, syscall.ETIMEDOUT):
+		return KindTimeout, "the request timed out"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindTimeout, "the request timed out"
+	}
+	return KindNetwork, err.Error()
+}
+
+func certificateInvalidReason(err x509.CertificateInvalidError) string {
+	switch err.Reason {
+	case x509.Expired:
+		return "TLS certificate has expired or is not yet valid"
+	case x509.NotAuthorizedToSign:
+		return "TLS certificate was signed by a certificate that is not a CA"
+	case x509.IncompatibleUsage:
+		return "TLS certificate is not valid for serving websites"
+	}
+	return "TLS certificate " + strings.TrimPrefix(err.Error(), "x509: certificate ")
+}
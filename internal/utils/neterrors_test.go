@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MongoCaleb/checker/internal/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyErrors(t *testing.T) {
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+
+	untrusted := httptest.NewTLSServer(http.NotFoundHandler())
+	defer untrusted.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	redirects := redirectServer()
+	defer redirects.Close()
+
+	cfg, err := sources.NewCheckerConfig([]byte(`{"hosts": [{"pattern": "^` + slow.URL + `", "timeout": "50ms"}]}`))
+	assert.NoError(t, err)
+	checker := NewHTTPChecker(cfg)
+
+	cases := []struct {
+		name    string
+		url     string
+		kind    string
+		message string
+	}{{
+		name:    "connection refused",
+		url:     refused.URL,
+		kind:    KindConnectionRefused,
+		message: "the connection was refused",
+	}, {
+		name:    "no such host",
+		url:     "http://checker-classify.invalid/",
+		kind:    KindDNS,
+		message: "the domain checker-classify.invalid does not exist",
+	}, {
+		name:    "unknown authority",
+		url:     untrusted.URL,
+		kind:    KindTLS,
+		message: "TLS certificate is signed by an unknown authority",
+	}, {
+		name:    "timeout",
+		url:     slow.URL,
+		kind:    KindTimeout,
+		message: "the request timed out",
+	}, {
+		name:    "closed without response",
+		url:     "http://" + listener.Addr().String(),
+		kind:    KindConnectionReset,
+		message: "the server closed the connection",
+	}, {
+		name:    "redirect loop",
+		url:     redirects.URL + "/loop-a",
+		kind:    KindRedirectLoop,
+		message: "redirect loop: ",
+	}, {
+		name:    "malformed url",
+		url:     "https://example.com/%zz",
+		kind:    KindInvalidURI,
+		message: `invalid URL escape "%zz"`,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp, ok := checker.Check(context.Background(), c.url)
+			assert.False(t, ok)
+			assert.Equal(t, c.kind, resp.Kind)
+			assert.True(t, strings.HasPrefix(resp.Message, c.message), resp.Message)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{LevelError, LevelWarning, LevelInfo} {
+		parsed, ok := ParseLevel(l.String())
+		assert.True(t, ok)
+		assert.Equal(t, l, parsed)
+	}
+	_, ok := ParseLevel("fatal")
+	assert.False(t, ok)
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only say where a visitor came from. Parameters starting
+// with utm_ are tracking parameters too.
+var trackingParams = map[string]bool{
+	"gclid": true, "fbclid": true, "msclkid": true, "yclid": true, "mc_cid": true, "mc_eid": true,
+	"_ga": true, "_gl": true, "_hsenc": true, "_hsmi": true, "mkt_tok": true,
+}
+
+// NormalizeURL returns the url that is fetched to check uri: the scheme and host are lower-cased, a
+// default port is dropped, an empty path becomes "/", and the fragment and tracking parameters are
+// removed. Everything else, including the order and escaping of the remaining parameters, is kept.
+func NormalizeURL(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return uri
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	if u.Path == "" && u.RawPath == "" {
+		u.Path = "/"
+	}
+	u.Fragment, u.RawFragment = "", ""
+	u.RawQuery = stripTracking(u.RawQuery)
+	u.ForceQuery = false
+	return u.String()
+}
+
+// URLKey returns the key under which spellings of the same page are grouped in the --spellings report. It
+// is the normalized url without a trailing slash on the path, since sites serve /a and /a/ as the same page,
+// often by redirecting one to the other. Links are checked by their normalized url, so that such a redirect
+// is reported for the spelling that has it.
+func URLKey(uri string) string {
+	normalized := NormalizeURL(uri)
+	u, err := url.Parse(normalized)
+	if err != nil || u.Path == "/" {
+		return normalized
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	return u.String()
+}
+
+func stripTracking(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	kept := make([]string, 0)
+	for _, param := range strings.Split(rawQuery, "&") {
+		name := param
+		if i := strings.Index(param, "="); i >= 0 {
+			name = param[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = strings.ToLower(unescaped)
+		}
+		if param == "" || trackingParams[name] || strings.HasPrefix(name, "utm_") {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	return strings.Join(kept, "&")
+}
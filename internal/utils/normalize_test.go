@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	cases := []struct {
+		url        string
+		normalized string
+		key        string
+	}{{
+		url:        "https://www.mongodb.com/docs/manual/",
+		normalized: "https://www.mongodb.com/docs/manual/",
+		key:        "https://www.mongodb.com/docs/manual",
+	}, {
+		url:        "HTTPS://WWW.MongoDB.com:443/docs/manual#anchor",
+		normalized: "https://www.mongodb.com/docs/manual",
+		key:        "https://www.mongodb.com/docs/manual",
+	}, {
+		url:        "http://example.com",
+		normalized: "http://example.com/",
+		key:        "http://example.com/",
+	}, {
+		url:        "http://example.com:80/#top",
+		normalized: "http://example.com/",
+		key:        "http://example.com/",
+	}, {
+		url:        "https://example.com:8443/a",
+		normalized: "https://example.com:8443/a",
+		key:        "https://example.com:8443/a",
+	}, {
+		url:        "https://example.com/a/?utm_source=docs&b=2&utm_medium=web&a=1&gclid=xyz",
+		normalized: "https://example.com/a/?b=2&a=1",
+		key:        "https://example.com/a?b=2&a=1",
+	}, {
+		url:        "https://example.com/a?utm_campaign=x",
+		normalized: "https://example.com/a",
+		key:        "https://example.com/a",
+	}, {
+		url:        "https://example.com/Case/Sensitive%2Fpath?q=A%20B",
+		normalized: "https://example.com/Case/Sensitive%2Fpath?q=A%20B",
+		key:        "https://example.com/Case/Sensitive%2Fpath?q=A%20B",
+	}, {
+		url:        "mailto:docs@mongodb.com",
+		normalized: "mailto:docs@mongodb.com",
+		key:        "mailto:docs@mongodb.com",
+	}}
+	for _, c := range cases {
+		assert.Equal(t, c.normalized, NormalizeURL(c.url), c.url)
+		assert.Equal(t, c.key, URLKey(c.url), c.url)
+	}
+}
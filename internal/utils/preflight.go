@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const preflightTimeout = 10 * time.Second
+
+// Origin returns the scheme, host and port of an http(s) uri, which is what Preflight checks.
+func Origin(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.Scheme + "://" + strings.ToLower(hostPort(u))
+}
+
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// Preflight checks that the host serving uri resolves and accepts connections, including a TLS handshake for
+// https, so that a host that is down can be reported once instead of once for every url on it. For https it
+// also returns a report on the certificate chain the host served, even if that chain failed verification.
+// Hosts reached through a proxy can't be checked directly and always pass.
+func (c *HTTPChecker) Preflight(ctx context.Context, uri string) (*CertificateReport, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if req, err := http.NewRequest("GET", uri, nil); err == nil {
+		if proxy, err := http.ProxyFromEnvironment(req); err == nil && proxy != nil {
+			return nil, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+		return nil, fmt.Errorf("DNS lookup failed: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort(u))
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	defer conn.Close()
+
+	if u.Scheme != "https" {
+		return nil, nil
+	}
+	return c.handshake(ctx, conn, u.Hostname())
+}
+
+// handshake verifies the chain and the hostname separately, rather than letting crypto/tls do both, so
+// that an untrusted certificate, or one for the wrong host, is reported as a problem with the certificate
+// and the host's links are still checked.
+func (c *HTTPChecker) handshake(ctx context.Context, conn net.Conn, host string) (*CertificateReport, error) {
+	cfg := &tls.Config{}
+	if t, ok := c.Client.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	roots := cfg.RootCAs
+	cfg.ServerName = host
+	cfg.InsecureSkipVerify = true
+
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	chain := tlsConn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("TLS handshake failed: no certificate served")
+	}
+	report := newCertificateReport(host, chain)
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := chain[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		_, report.VerifyError = ClassifyError(err)
+	}
+	if err := chain[0].VerifyHostname(host); err != nil {
+		report.HostnameError = err.Error()
+	}
+	return report, nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreflight(t *testing.T) {
+	up := httptest.NewServer(http.NotFoundHandler())
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	secure := httptest.NewTLSServer(http.NotFoundHandler())
+	defer secure.Close()
+
+	trusted := NewHTTPChecker(nil)
+	trusted.Client = secure.Client()
+
+	cases := []struct {
+		name    string
+		checker *HTTPChecker
+		url     string
+		err     string
+	}{{
+		name:    "up",
+		checker: NewHTTPChecker(nil),
+		url:     up.URL + "/some/page",
+	}, {
+		name:    "connection refused",
+		checker: NewHTTPChecker(nil),
+		url:     down.URL + "/some/page",
+		err:     "connection failed",
+	}, {
+		name:    "no such host",
+		checker: NewHTTPChecker(nil),
+		url:     "http://checker-preflight.invalid/",
+		err:     "DNS lookup failed",
+	}, {
+		name:    "untrusted certificate",
+		checker: NewHTTPChecker(nil),
+		url:     secure.URL,
+	}, {
+		name:    "trusted certificate",
+		checker: trusted,
+		url:     secure.URL,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.checker.Preflight(context.Background(), c.url)
+			if c.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.True(t, strings.HasPrefix(err.Error(), c.err), err.Error())
+			}
+		})
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	assert.Equal(t, "https://www.mongodb.com:443", Origin("https://www.mongodb.com/docs/manual/#anchor"))
+	assert.Equal(t, "http://www.mongodb.com:80", Origin("http://www.mongodb.com/docs"))
+	assert.Equal(t, "http://localhost:8080", Origin("http://localhost:8080/x"))
+	assert.Equal(t, "", Origin("not a url"))
+	assert.Equal(t, "", Origin("ftp://ftp.example.com/pub"))
+}
+
+func TestPreflightReplay(t *testing.T) {
+	up := httptest.NewServer(http.NotFoundHandler())
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	recorder := NewRecorder(NewHTTPChecker(nil))
+	_, err := recorder.Preflight(context.Background(), up.URL+"/a")
+	assert.NoError(t, err)
+	_, liveErr := recorder.Preflight(context.Background(), down.URL+"/a")
+	assert.Error(t, liveErr)
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	assert.NoError(t, recorder.Save(fixture))
+
+	replay, err := LoadReplay(fixture)
+	assert.NoError(t, err)
+	_, err = replay.Preflight(context.Background(), up.URL+"/b")
+	assert.NoError(t, err)
+	_, err = replay.Preflight(context.Background(), down.URL+"/b")
+	assert.Error(t, err)
+	liveKind, liveReason := ClassifyError(liveErr)
+	kind, reason := ClassifyError(err)
+	assert.Equal(t, KindConnectionRefused, kind)
+	assert.Equal(t, liveKind, kind, "a replayed failure keeps its class")
+	assert.Equal(t, liveReason, reason)
+}
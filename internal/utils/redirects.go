@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const maxRedirects = 10
+
+// KindRedirect marks diagnostics for links whose redirect chain should be updated in the source.
+const KindRedirect = "redirect"
+
+// Redirect is a single hop in a redirect chain.
+type Redirect struct {
+	Code int    `json:"code"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// FinalURL returns the URL the redirect chain ends at, or "" if the request was not redirected.
+func (r HttpResponse) FinalURL() string {
+	if len(r.Redirects) == 0 {
+		return ""
+	}
+	return r.Redirects[len(r.Redirects)-1].To
+}
+
+// Chain renders the redirect chain as "from -[code]-> to -[code]-> ...".
+func (r HttpResponse) Chain() string {
+	if len(r.Redirects) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(r.Redirects[0].From)
+	for _, hop := range r.Redirects {
+		sb.WriteString(fmt.Sprintf(" -[%d]-> %s", hop.Code, hop.To))
+	}
+	return sb.String()
+}
+
+// RedirectReasons lists why a followed redirect chain is worth reporting: permanent redirects,
+// hops to another host, and http to https upgrades. Temporary same-host redirects are not reported.
+func (r HttpResponse) RedirectReasons() []string {
+	reasons := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(reason string) {
+		if !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	for _, hop := range r.Redirects {
+		if hop.Code == 301 || hop.Code == 308 {
+			add("permanent redirect")
+		}
+		from, err := url.Parse(hop.From)
+		if err != nil {
+			continue
+		}
+		to, err := url.Parse(hop.To)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(from.Hostname(), to.Hostname()) {
+			add("cross-domain redirect")
+		}
+		if from.Scheme == "http" && to.Scheme == "https" {
+			add("http to https redirect")
+		}
+	}
+	return reasons
+}
+
+// PermanentTarget returns where the leading run of permanent (301/308) redirects ends, which is the url
+// a link can safely be replaced with. It returns "" if the first hop is not permanent.
+func (r HttpResponse) PermanentTarget() string {
+	target := ""
+	for _, hop := range r.Redirects {
+		if hop.Code != 301 && hop.Code != 308 {
+			break
+		}
+		target = hop.To
+	}
+	return target
+}
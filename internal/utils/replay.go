@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Recording is what a LinkChecker answered for one url. Body is only present if the url was fetched
+// successfully. Unreachable and Certificate are recorded against an Origin by Preflight, Unreachable along
+// with UnreachableKind, the class of the failure such as dns-error.
+type Recording struct {
+	Code            int                `json:"code"`
+	OK              bool               `json:"ok"`
+	Accepted        bool               `json:"accepted,omitempty"`
+	Kind            string             `json:"kind,omitempty"`
+	Message         string             `json:"message,omitempty"`
+	Header          http.Header        `json:"headers,omitempty"`
+	Redirects       []Redirect         `json:"redirects,omitempty"`
+	Body            *[]byte            `json:"body,omitempty"`
+	Unreachable     string             `json:"unreachable,omitempty"`
+	UnreachableKind string             `json:"unreachable_kind,omitempty"`
+	Certificate     *CertificateReport `json:"certificate,omitempty"`
+	checked         bool
+}
+
+// Recordings maps urls to what was recorded for them. It is the format of --record and --replay files.
+type Recordings map[string]*Recording
+
+// Recorder is a LinkChecker that passes every call through to another one and remembers the answers.
+type Recorder struct {
+	checker    LinkChecker
+	mu         sync.Mutex
+	recordings Recordings
+}
+
+func NewRecorder(checker LinkChecker) *Recorder {
+	return &Recorder{checker: checker, recordings: make(Recordings)}
+}
+
+func (r *Recorder) get(uri string) *Recording {
+	rec, ok := r.recordings[uri]
+	if !ok {
+		rec = &Recording{}
+		r.recordings[uri] = rec
+	}
+	return rec
+}
+
+// Check records the answer unless ctx was cancelled, since an interrupted check says nothing about uri.
+func (r *Recorder) Check(ctx context.Context, uri string) (HttpResponse, bool) {
+	resp, ok := r.checker.Check(ctx, uri)
+	if ctx.Err() != nil {
+		return resp, ok
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.get(uri)
+	rec.checked = true
+	rec.Code = resp.Code
+	rec.OK = ok
+	rec.Accepted = resp.Accepted
+	rec.Kind = resp.Kind
+	rec.Message = resp.Message
+	rec.Header = resp.Header
+	rec.Redirects = resp.Redirects
+	return resp, ok
+}
+
+func (r *Recorder) Fetch(ctx context.Context, uri string) ([]byte, bool) {
+	body, ok := r.checker.Fetch(ctx, uri)
+	if ctx.Err() != nil {
+		return body, ok
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.get(uri)
+	if ok {
+		b := append([]byte{}, body...)
+		rec.Body = &b
+		if !rec.checked {
+			rec.Code = 200
+			rec.OK = true
+		}
+	}
+	return body, ok
+}
+
+// Preflight records failures and certificates only; origins without a recording are reachable when replayed.
+func (r *Recorder) Preflight(ctx context.Context, uri string) (*CertificateReport, error) {
+	cert, err := r.checker.Preflight(ctx, uri)
+	if (err == nil && cert == nil) || ctx.Err() != nil {
+		return cert, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := r.get(Origin(uri))
+	rec.Certificate = cert
+	if err != nil {
+		rec.UnreachableKind, rec.Unreachable = ClassifyError(err)
+	}
+	return cert, err
+}
+
+// Save writes everything recorded so far to path as JSON.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out, err := json.MarshalIndent(r.recordings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(out, '\n'), 0644)
+}
+
+// ReplayChecker is a LinkChecker that answers from Recordings without touching the network. Urls that
+// were not recorded are unreachable.
+type ReplayChecker struct {
+	recordings Recordings
+}
+
+func NewReplayChecker(recordings Recordings) *ReplayChecker {
+	return &ReplayChecker{recordings: recordings}
+}
+
+// LoadReplay reads a file written by Recorder.Save.
+func LoadReplay(path string) (*ReplayChecker, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	recordings := make(Recordings)
+	if err := json.Unmarshal(body, &recordings); err != nil {
+		return nil, err
+	}
+	return NewReplayChecker(recordings), nil
+}
+
+func (c *ReplayChecker) Check(ctx context.Context, uri string) (HttpResponse, bool) {
+	var r HttpResponse
+	rec, ok := c.recordings[uri]
+	if !ok {
+		log.Debugf("no recording for %s", uri)
+		r.Kind = KindNotRecorded
+		r.Message = "not in the replay file"
+		return r, false
+	}
+	r.Code = rec.Code
+	r.Accepted = rec.Accepted
+	r.Kind = rec.Kind
+	r.Message = rec.Message
+	r.Header = rec.Header
+	r.Redirects = rec.Redirects
+	return r, rec.OK
+}
+
+func (c *ReplayChecker) Fetch(ctx context.Context, uri string) ([]byte, bool) {
+	rec, ok := c.recordings[uri]
+	if !ok || rec.Body == nil {
+		log.Debugf("no recorded body for %s", uri)
+		return nil, false
+	}
+	return *rec.Body, true
+}
+
+func (c *ReplayChecker) Preflight(ctx context.Context, uri string) (*CertificateReport, error) {
+	rec, ok := c.recordings[Origin(uri)]
+	if !ok {
+		return nil, nil
+	}
+	if rec.Unreachable != "" && rec.UnreachableKind != "" {
+		return rec.Certificate, &ClassifiedError{Kind: rec.UnreachableKind, Err: errors.New(rec.Unreachable)}
+	}
+	if rec.Unreachable != "" {
+		return rec.Certificate, errors.New(rec.Unreachable)
+	}
+	return rec.Certificate, nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", "test")
+		w.Write([]byte("\x00binary\xffbody"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+	})
+	srv := httptest.NewServer(mux)
+
+	recorder := NewRecorder(NewHTTPChecker(nil))
+	urls := []string{srv.URL + "/ok", srv.URL + "/moved", srv.URL + "/missing"}
+	type result struct {
+		resp HttpResponse
+		ok   bool
+	}
+	live := make(map[string]result)
+	for _, u := range urls {
+		resp, ok := recorder.Check(context.Background(), u)
+		live[u] = result{resp, ok}
+	}
+	liveBody, ok := recorder.Fetch(context.Background(), srv.URL+"/moved")
+	assert.True(t, ok)
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	assert.NoError(t, recorder.Save(fixture))
+	srv.Close()
+
+	replay, err := LoadReplay(fixture)
+	assert.NoError(t, err)
+	for _, u := range urls {
+		resp, ok := replay.Check(context.Background(), u)
+		assert.Equal(t, live[u].ok, ok, u)
+		assert.Equal(t, live[u].resp.Code, resp.Code, u)
+		assert.Equal(t, live[u].resp.Redirects, resp.Redirects, u)
+		assert.Equal(t, live[u].resp.Header.Get("X-Served-By"), resp.Header.Get("X-Served-By"), u)
+	}
+	body, ok := replay.Fetch(context.Background(), srv.URL+"/moved")
+	assert.True(t, ok)
+	assert.Equal(t, liveBody, body)
+
+	_, ok = replay.Check(context.Background(), srv.URL+"/never-recorded")
+	assert.False(t, ok)
+	_, ok = replay.Fetch(context.Background(), srv.URL+"/missing")
+	assert.False(t, ok)
+}
+
+func TestReplayFixture(t *testing.T) {
+	replay := NewReplayChecker(Recordings{
+		"http://docs.mongodb.com/manual/": {
+			Code: 200,
+			OK:   true,
+			Redirects: []Redirect{
+				{Code: 301, From: "http://docs.mongodb.com/manual/", To: "https://www.mongodb.com/docs/manual/"},
+			},
+		},
+		"https://www.linkedin.com/company/mongodbinc": {Code: 999, OK: true, Accepted: true},
+	})
+
+	resp, ok := replay.Check(context.Background(), "http://docs.mongodb.com/manual/")
+	assert.True(t, ok)
+	assert.Equal(t, "https://www.mongodb.com/docs/manual/", resp.PermanentTarget())
+
+	resp, ok = replay.Check(context.Background(), "https://www.linkedin.com/company/mongodbinc")
+	assert.True(t, ok)
+	assert.True(t, resp.Accepted)
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Kinds of diagnostics for links with a scheme other than http(s).
+const (
+	KindInvalidURI = "invalid-uri"
+	KindNoMX       = "no-mx"
+)
+
+var (
+	hostnameRegex = regexp.MustCompile(`^(?i)([a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?\.)*[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	// RFC 3966: a global number, or a local one with a phone-context, followed by parameters
+	globalPhoneRegex = regexp.MustCompile(`^\+[-.()0-9]*[0-9][-.()0-9]*(;[-a-zA-Z0-9]+(=[^;]*)?)*$`)
+	localPhoneRegex  = regexp.MustCompile(`^[-.()0-9a-fA-F*#]*[0-9a-fA-F*#][-.()0-9a-fA-F*#]*(;[-a-zA-Z0-9]+(=[^;]*)?)*$`)
+	scpLikeRegex     = regexp.MustCompile(`^[-a-zA-Z0-9_.]+@([^:/]+):([^\s]+)$`)
+)
+
+// Resolver is the part of net.Resolver that SchemeChecker uses, so that tests can stub DNS.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// SchemeChecker checks mailto, tel, ftp, git and ssh links. Syntax is always validated; CheckMX looks up
+// the mail servers of mailto domains and DialFTP connects to ftp servers and waits for their greeting.
+type SchemeChecker struct {
+	Resolver Resolver
+	Dialer   interface {
+		DialContext(ctx context.Context, network, address string) (net.Conn, error)
+	}
+	CheckMX bool
+	DialFTP bool
+}
+
+func NewSchemeChecker(checkMX, dialFTP bool) *SchemeChecker {
+	return &SchemeChecker{
+		Resolver: net.DefaultResolver,
+		Dialer:   &net.Dialer{Timeout: preflightTimeout},
+		CheckMX:  checkMX,
+		DialFTP:  dialFTP,
+	}
+}
+
+// Check validates uri and, if enabled, checks that its mail domain or ftp server is live. Failures are
+// reported with a Kind and an explanation in Message, like network failures from HTTPChecker.
+func (c *SchemeChecker) Check(ctx context.Context, uri string) (HttpResponse, bool) {
+	var r HttpResponse
+	if m := scpLikeRegex.FindStringSubmatch(uri); m != nil {
+		if err := validateHost(m[1]); err != nil {
+			r.Kind, r.Message = KindInvalidURI, err.Error()
+			return r, false
+		}
+		return r, true
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		r.Kind, r.Message = KindInvalidURI, err.Error()
+		return r, false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "mailto":
+		domains, err := mailDomains(u)
+		if err != nil {
+			r.Kind, r.Message = KindInvalidURI, err.Error()
+			return r, false
+		}
+		if c.CheckMX {
+			for _, domain := range domains {
+				if err := c.checkMX(ctx, domain); err != nil {
+					r.Kind, r.Message = KindNoMX, err.Error()
+					return r, false
+				}
+			}
+		}
+	case "tel":
+		if err := validatePhone(u.Opaque); err != nil {
+			r.Kind, r.Message = KindInvalidURI, err.Error()
+			return r, false
+		}
+	case "ftp", "ftps", "sftp", "git", "ssh", "git+ssh":
+		if err := validateHost(u.Hostname()); err != nil {
+			r.Kind, r.Message = KindInvalidURI, err.Error()
+			return r, false
+		}
+		if u.Port() != "" {
+			if _, err := net.LookupPort("tcp", u.Port()); err != nil {
+				r.Kind, r.Message = KindInvalidURI, fmt.Sprintf("invalid port %q", u.Port())
+				return r, false
+			}
+		}
+		if c.DialFTP && strings.EqualFold(u.Scheme, "ftp") {
+			if err := c.dialFTP(ctx, u); err != nil {
+				r.Kind, r.Message = ClassifyError(err)
+				return r, false
+			}
+		}
+	default:
+		r.Kind, r.Message = KindInvalidURI, fmt.Sprintf("unsupported scheme %q", u.Scheme)
+		return r, false
+	}
+	return r, true
+}
+
+func validateHost(host string) error {
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if len(host) > 253 || !hostnameRegex.MatchString(host) {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	return nil
+}
+
+// mailDomains validates every address in a mailto url and returns their domains. Addresses can be given
+// in the path, comma-separated, or in to, cc and bcc query parameters.
+func mailDomains(u *url.URL) ([]string, error) {
+	to, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0)
+	for _, a := range strings.Split(to, ",") {
+		if strings.TrimSpace(a) != "" {
+			addresses = append(addresses, strings.TrimSpace(a))
+		}
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"to", "cc", "bcc"} {
+		for _, v := range query[key] {
+			addresses = append(addresses, strings.Split(v, ",")...)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no email address")
+	}
+
+	domains := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		addr, err := mail.ParseAddress(strings.TrimSpace(a))
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address %q", a)
+		}
+		domain := addr.Address[strings.LastIndex(addr.Address, "@")+1:]
+		if err := validateHost(domain); err != nil || !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("invalid email domain %q", domain)
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+func validatePhone(number string) error {
+	if globalPhoneRegex.MatchString(number) {
+		return nil
+	}
+	if localPhoneRegex.MatchString(number) && strings.Contains(number, ";phone-context=") {
+		return nil
+	}
+	return fmt.Errorf("invalid phone number %q, expected +<country code><number>", number)
+}
+
+// checkMX reports an error if domain has no mail servers. A null MX (RFC 7505) says the domain accepts
+// no mail at all.
+func (c *SchemeChecker) checkMX(ctx context.Context, domain string) error {
+	records, err := c.Resolver.LookupMX(ctx, domain)
+	if err != nil {
+		_, explanation := ClassifyError(err)
+		return fmt.Errorf("no MX records for %s: %s", domain, explanation)
+	}
+	if len(records) == 0 || (len(records) == 1 && records[0].Host == ".") {
+		return fmt.Errorf("%s does not accept email", domain)
+	}
+	return nil
+}
+
+// dialFTP connects to an ftp server and expects its 220 greeting.
+func (c *SchemeChecker) dialFTP(ctx context.Context, u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		port = "21"
+	}
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	conn, err := c.Dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	greeting, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "220") {
+		return fmt.Errorf("not an ftp server: %q", strings.TrimSpace(greeting))
+	}
+	return nil
+}
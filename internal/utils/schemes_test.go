@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubResolver map[string][]*net.MX
+
+func (s stubResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if records, ok := s[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestSchemeCheckerSyntax(t *testing.T) {
+	checker := NewSchemeChecker(false, false)
+	cases := []struct {
+		uri  string
+		ok   bool
+		kind string
+	}{
+		{"mailto:support@mongodb.com", true, ""},
+		{"mailto:a@mongodb.com,b@mongodb.com?subject=Hi%20there", true, ""},
+		{"mailto:?to=docs@mongodb.com&cc=support@mongodb.com", true, ""},
+		{"mailto:support%40mongodb.com", true, ""},
+		{"mailto:support@mongodb", false, KindInvalidURI},
+		{"mailto:support.mongodb.com", false, KindInvalidURI},
+		{"mailto:", false, KindInvalidURI},
+		{"tel:+1-866-237-8815", true, ""},
+		{"tel:+44(0)20.1234.5678;ext=22", true, ""},
+		{"tel:7042;phone-context=example.com", true, ""},
+		{"tel:866-237-8815", false, KindInvalidURI},
+		{"tel:+", false, KindInvalidURI},
+		{"ftp://ftp.example.com/pub/", true, ""},
+		{"ftp://ftp_example.com/pub/", false, KindInvalidURI},
+		{"sftp://files.example.com:2222/drop", true, ""},
+		{"git://github.com/mongodb/mongo.git", true, ""},
+		{"ssh://git@github.com/mongodb/mongo.git", true, ""},
+		{"ssh://git@github.com:notaport/mongo.git", false, KindInvalidURI},
+		{"git@github.com:mongodb/mongo.git", true, ""},
+		{"git@-github.com:mongodb/mongo.git", false, KindInvalidURI},
+		{"gopher://example.com", false, KindInvalidURI},
+	}
+	for _, c := range cases {
+		t.Run(c.uri, func(t *testing.T) {
+			resp, ok := checker.Check(context.Background(), c.uri)
+			assert.Equal(t, c.ok, ok, resp.Message)
+			assert.Equal(t, c.kind, resp.Kind)
+		})
+	}
+}
+
+func TestSchemeCheckerMX(t *testing.T) {
+	checker := NewSchemeChecker(true, false)
+	checker.Resolver = stubResolver{
+		"mongodb.com":   {{Host: "aspmx.l.google.com.", Pref: 1}},
+		"nomail.com":    {{Host: ".", Pref: 0}},
+		"example.co.uk": {},
+	}
+
+	resp, ok := checker.Check(context.Background(), "mailto:support@mongodb.com")
+	assert.True(t, ok, resp.Message)
+
+	resp, ok = checker.Check(context.Background(), "mailto:support@mongodb.com,info@missing.example")
+	assert.False(t, ok)
+	assert.Equal(t, KindNoMX, resp.Kind)
+	assert.Equal(t, "no MX records for missing.example: the domain missing.example does not exist (NXDOMAIN)", resp.Message)
+
+	resp, ok = checker.Check(context.Background(), "mailto:info@nomail.com")
+	assert.False(t, ok)
+	assert.Equal(t, "nomail.com does not accept email", resp.Message)
+
+	_, ok = checker.Check(context.Background(), "mailto:info@example.co.uk")
+	assert.False(t, ok)
+}
+
+func TestSchemeCheckerFTP(t *testing.T) {
+	serve := func(greeting string) (string, func()) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conn.Write([]byte(greeting))
+				conn.Close()
+			}
+		}()
+		return listener.Addr().String(), func() { listener.Close() }
+	}
+	ftp, stopFTP := serve("220 Welcome\r\n")
+	defer stopFTP()
+	smtp, stopSMTP := serve("554 No thanks\r\n")
+	defer stopSMTP()
+	closed, stopClosed := serve("")
+	stopClosed()
+
+	checker := NewSchemeChecker(false, true)
+	resp, ok := checker.Check(context.Background(), "ftp://"+ftp+"/pub/")
+	assert.True(t, ok, resp.Message)
+
+	resp, ok = checker.Check(context.Background(), "ftp://"+smtp+"/pub/")
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(resp.Message, "not an ftp server"), resp.Message)
+
+	resp, ok = checker.Check(context.Background(), "ftp://"+closed+"/pub/")
+	assert.False(t, ok)
+	assert.Equal(t, KindConnectionRefused, resp.Kind)
+
+	_, ok = NewSchemeChecker(false, false).Check(context.Background(), "ftp://"+closed+"/pub/")
+	assert.True(t, ok, "ftp servers are only dialed when asked to")
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/MongoCaleb/checker/internal/sources"
+)
+
+// KindSoft404 marks diagnostics for pages that answer 200 but are really "not found" pages.
+const KindSoft404 = "soft-404"
+
+// soft404ProbePath is requested on a host to learn what its "not found" page looks like.
+const soft404ProbePath = "/checker-soft-404-probe-7f3b9c1e"
+
+var (
+	titleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	wordRegex  = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	probes     sync.Map
+)
+
+type probeResult struct {
+	once  sync.Once
+	words map[string]bool
+	ok    bool
+}
+
+// Soft404 applies rule to a uri that a LinkChecker reported as reachable and returns which heuristic
+// identified it as a missing page, if any.
+func Soft404(ctx context.Context, checker LinkChecker, uri string, resp HttpResponse, rule *sources.Soft404Rule) (string, bool) {
+	if rule == nil {
+		return "", false
+	}
+	if rule.RootRedirect && redirectsToRoot(uri, resp) {
+		return fmt.Sprintf("redirects to the site root %s", resp.FinalURL()), true
+	}
+	if !rule.NeedsBody() {
+		return "", false
+	}
+
+	body, ok := checker.Fetch(ctx, uri)
+	if !ok {
+		return "", false
+	}
+	if m := titleRegex.FindSubmatch(body); m != nil && rule.MatchTitle(strings.TrimSpace(string(m[1]))) {
+		return fmt.Sprintf("page title %q matches %q", strings.TrimSpace(string(m[1])), rule.Title), true
+	}
+	if rule.MatchBody(body) {
+		return fmt.Sprintf("page body matches %q", rule.Body), true
+	}
+	if rule.Probe {
+		if probe, ok := probeWords(ctx, checker, uri); ok {
+			if s := similarity(words(body), probe); s >= rule.Similarity {
+				return fmt.Sprintf("page is %.0f%% identical to a missing page on the same host", s*100), true
+			}
+		}
+	}
+	return "", false
+}
+
+func redirectsToRoot(uri string, resp HttpResponse) bool {
+	final := resp.FinalURL()
+	if final == "" {
+		return false
+	}
+	from, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	to, err := url.Parse(final)
+	if err != nil {
+		return false
+	}
+	isRoot := func(u *url.URL) bool { return u.Path == "" || u.Path == "/" }
+	return isRoot(to) && !isRoot(from)
+}
+
+// probeWords fetches the probe page once per host and returns its words.
+func probeWords(ctx context.Context, checker LinkChecker, uri string) (map[string]bool, bool) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, false
+	}
+	root := u.Scheme + "://" + u.Host
+	v, _ := probes.LoadOrStore(root, &probeResult{})
+	probe := v.(*probeResult)
+	probe.once.Do(func() {
+		if body, ok := checker.Fetch(ctx, root+soft404ProbePath); ok {
+			probe.words = words(body)
+			probe.ok = true
+		}
+	})
+	return probe.words, probe.ok
+}
+
+func words(body []byte) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range wordRegex.FindAll(body, -1) {
+		set[strings.ToLower(string(w))] = true
+	}
+	return set
+}
+
+// similarity is the Jaccard index of two word sets.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
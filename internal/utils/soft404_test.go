@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MongoCaleb/checker/internal/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+const notFoundPage = `<html><head><title>Page Not Found</title></head>
+<body><h1>Sorry</h1><p>We couldn't find the page you were looking for. Try the search box above.</p></body></html>`
+
+func soft404Server() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte("<html><head><title>Home</title></head><body>Welcome to the vendor docs</body></html>"))
+			return
+		}
+		// every unknown page gets the same "not found" page with a 200
+		w.Write([]byte(notFoundPage))
+	})
+	mux.HandleFunc("/docs/real", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>Installing the driver</title></head><body>Run the installer, then connect with a connection string.</body></html>"))
+	})
+	mux.HandleFunc("/docs/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusMovedPermanently)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestSoft404(t *testing.T) {
+	srv := soft404Server()
+	defer srv.Close()
+
+	cfg, err := sources.NewCheckerConfig([]byte(`{"soft404": [
+		{"host": "title.test", "title": "(?i)not found"},
+		{"host": "body.test", "body": "couldn't find the page"},
+		{"host": "root.test", "root_redirect": true},
+		{"host": "probe.test", "probe": true}
+	]}`))
+	assert.NoError(t, err)
+
+	cases := []struct {
+		rule   string
+		path   string
+		soft   bool
+		reason string
+	}{
+		{rule: "title.test", path: "/docs/missing", soft: true, reason: `page title "Page Not Found" matches "(?i)not found"`},
+		{rule: "title.test", path: "/docs/real", soft: false},
+		{rule: "body.test", path: "/docs/missing", soft: true, reason: `page body matches "couldn't find the page"`},
+		{rule: "body.test", path: "/docs/real", soft: false},
+		{rule: "root.test", path: "/docs/moved", soft: true, reason: "redirects to the site root " + srv.URL + "/"},
+		{rule: "root.test", path: "/docs/missing", soft: false},
+		{rule: "probe.test", path: "/docs/missing", soft: true, reason: "page is 100% identical to a missing page on the same host"},
+		{rule: "probe.test", path: "/docs/real", soft: false},
+	}
+
+	checker := NewHTTPChecker(nil)
+	for _, c := range cases {
+		t.Run(c.rule+c.path, func(t *testing.T) {
+			resp, ok := checker.Check(context.Background(), srv.URL+c.path)
+			assert.True(t, ok)
+			reason, soft := Soft404(context.Background(), checker, srv.URL+c.path, resp, cfg.Soft404For(c.rule))
+			assert.Equal(t, c.soft, soft)
+			assert.Equal(t, c.reason, reason)
+		})
+	}
+
+	_, soft := Soft404(context.Background(), checker, srv.URL+"/docs/missing", HttpResponse{Code: 200}, nil)
+	assert.False(t, soft, "hosts without a rule are never soft 404s")
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity(words([]byte("a b c")), words([]byte("C B A"))))
+	assert.Equal(t, 0.5, similarity(words([]byte("a b c")), words([]byte("b c d"))))
+	assert.Equal(t, 0.0, similarity(words([]byte("a")), words([]byte("b"))))
+}
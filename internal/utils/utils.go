@@ -2,24 +2,66 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
-	"regexp"
-	"strings"
-	"time"
 
+	"github.com/MongoCaleb/checker/internal/parsers/links"
 	"github.com/google/go-github/v41/github"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	rstSpecBase = "https://raw.githubusercontent.com/mongodb/snooty-parser/"
+	rstSpecBase      = "https://raw.githubusercontent.com/mongodb/snooty-parser/"
+	snootyParserTags = "https://api.github.com/repos/mongodb/snooty-parser/tags"
 )
 
+// Level is the severity of a diagnostic.
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarning
+	LevelInfo
+)
+
+const (
+	// KindAccepted marks notes for links whose non-200 status was accepted by the host settings.
+	KindAccepted = "accepted"
+	// KindSkipped marks links that were not checked because the run was interrupted or timed out.
+	KindSkipped = "skipped"
+)
+
+// ParseLevel is the inverse of Level.String.
+func ParseLevel(s string) (Level, bool) {
+	for _, l := range []Level{LevelError, LevelWarning, LevelInfo} {
+		if l.String() == s {
+			return l, true
+		}
+	}
+	return LevelError, false
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelWarning:
+		return "warning"
+	case LevelInfo:
+		return "info"
+	}
+	return "error"
+}
+
 type HttpResponse struct {
-	Code     int
-	Filename string
-	Message  string
+	Code      int
+	Level     Level
+	Kind      string
+	Accepted  bool
+	Filename  string
+	Message   string
+	Header    http.Header
+	Redirects []Redirect
 }
 
 type validRedirects [7]int
@@ -34,9 +76,8 @@ func (v validRedirects) contains(i int) bool {
 }
 
 var (
-	httpLinkRegex = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9@:%_\+.~#?&//=]*)`)
-	client        *http.Client
-	redirects     = validRedirects{301, 302, 303, 304, 305, 307, 308}
+	client    *http.Client
+	redirects = validRedirects{301, 302, 303, 304, 305, 307, 308}
 )
 
 func init() {
@@ -44,44 +85,34 @@ func init() {
 		PadLevelText:           true,
 		DisableLevelTruncation: false,
 	})
+	// the client has no Timeout: HTTPChecker times out each request, so that host settings can allow more
+	// than defaultRequestTimeout
 	client = &http.Client{
-		Timeout: time.Second * 30,
+		// redirects are followed by hand in HTTPChecker so that the chain can be recorded
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
 	}
 }
 
-func GetLatestSnootyParserTag() string {
-	ghClient := github.NewClient(nil)
-
-	gctx, gcancel := context.WithTimeout(context.TODO(), 5*time.Second)
-	defer gcancel()
-
-	// get the latest release
-	tags, _, err := ghClient.Repositories.ListTags(gctx, "mongodb", "snooty-parser", nil)
-	if err != nil {
-		log.Fatal(err)
+// GetLatestSnootyParserTag returns the url of rstspec.toml in the latest snooty-parser release.
+func GetLatestSnootyParserTag(ctx context.Context, checker LinkChecker) (string, error) {
+	body, ok := checker.Fetch(ctx, snootyParserTags)
+	if !ok {
+		return "", fmt.Errorf("could not list snooty-parser tags from %s", snootyParserTags)
 	}
 
-	latest := tags[0].Name
-	return rstSpecBase + *latest + "/snooty/rstspec.toml"
-}
-
-func GetNetworkFile(input string) []byte {
-
-	req, err := http.NewRequest("GET", input, nil)
-	if err != nil {
-		log.Fatal(err)
+	// get the latest release
+	var tags []*github.RepositoryTag
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return "", err
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Panicf("Could not get file %s: %v", input, err)
+	if len(tags) == 0 || tags[0].Name == nil {
+		return "", fmt.Errorf("no snooty-parser tags found at %s", snootyParserTags)
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Panic(err)
-	}
-	return body
+	latest := tags[0].Name
+	return rstSpecBase + *latest + "/snooty/rstspec.toml", nil
 }
 
 func GetLocalFile(input string) []byte {
@@ -93,49 +124,5 @@ func GetLocalFile(input string) []byte {
 }
 
 func IsHTTPLink(input string) bool {
-	return httpLinkRegex.MatchString(input)
-}
-
-func IsReachable(uri string) (HttpResponse, bool) {
-	// check to see if there's a way to avoid triggering page viewws
-	// block add blockers
-	// test net.DialTCP
-	// look at muffet to see what they do to make sure a url is valid
-
-	var r HttpResponse
-
-	req, err := http.NewRequest("GET", uri, nil)
-	req.Header.Set("Connection", "Keep-Alive")
-	req.Header.Set("Accept-Language", "en-US")
-	req.Header.Set("User-Agent", "Mozilla/5.0")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-
-	if err != nil {
-		log.Fatal(err)
-	}
-	response, err := client.Do(req)
-
-	if err != nil {
-		var code int
-		if response != nil {
-			code = response.StatusCode
-		}
-		if strings.Contains(err.Error(), "stopped after 10 redirects") {
-			if redirects.contains(code) {
-				return r, true
-			}
-		} else {
-			r.Code = code
-			return r, false
-		}
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode == 200 {
-		return r, true
-	} else {
-		r.Code = response.StatusCode
-		r.Message = req.URL.Path
-		return r, false
-	}
+	return links.HasHTTPLink(input)
 }
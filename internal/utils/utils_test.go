@@ -1,12 +1,29 @@
 package utils
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/MongoCaleb/checker/internal/sources"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
+// isReachable checks uri with a plain HTTPChecker.
+func isReachable(uri string) (HttpResponse, bool) {
+	return NewHTTPChecker(nil).Check(context.Background(), uri)
+}
+
 func TestUrls(t *testing.T) {
+	if _, err := net.LookupHost("example.com"); err != nil {
+		t.Skip("network unavailable: ", err)
+	}
 	cases := []struct {
 		url string
 		ok  bool
@@ -22,9 +39,254 @@ func TestUrls(t *testing.T) {
 	}}
 	for _, test := range cases {
 		t.Run(test.url, func(t *testing.T) {
-			err, ok := IsReachable(test.url)
-			assert.NoError(t, err)
-			assert.True(t, ok)
+			resp, ok := isReachable(test.url)
+			assert.Equal(t, 200, resp.Code)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
+
+func redirectServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/twice", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/loop-a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-b", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-a", http.StatusFound)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/missing", http.StatusPermanentRedirect)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestIsReachableRedirects(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+
+	cases := []struct {
+		path    string
+		ok      bool
+		code    int
+		hops    []int
+		reasons []string
+	}{{
+		path:    "/ok",
+		ok:      true,
+		code:    200,
+		hops:    []int{},
+		reasons: []string{},
+	}, {
+		path:    "/moved",
+		ok:      true,
+		code:    200,
+		hops:    []int{301},
+		reasons: []string{"permanent redirect"},
+	}, {
+		path:    "/temporary",
+		ok:      true,
+		code:    200,
+		hops:    []int{302},
+		reasons: []string{},
+	}, {
+		path:    "/twice",
+		ok:      true,
+		code:    200,
+		hops:    []int{307, 301},
+		reasons: []string{"permanent redirect"},
+	}, {
+		path:    "/loop-a",
+		ok:      false,
+		code:    302,
+		hops:    []int{302, 302},
+		reasons: []string{},
+	}, {
+		path:    "/gone",
+		ok:      false,
+		code:    404,
+		hops:    []int{308},
+		reasons: []string{"permanent redirect"},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			resp, ok := isReachable(srv.URL + c.path)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.code, resp.Code)
+			hops := make([]int, 0)
+			for _, hop := range resp.Redirects {
+				hops = append(hops, hop.Code)
+			}
+			assert.Equal(t, c.hops, hops)
+			assert.Equal(t, c.reasons, resp.RedirectReasons())
 		})
 	}
 }
+
+func TestRedirectLoopMessage(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+
+	resp, ok := isReachable(srv.URL + "/loop-a")
+	assert.False(t, ok)
+	assert.True(t, strings.HasPrefix(resp.Message, "redirect loop: "+srv.URL+"/loop-a"), resp.Message)
+}
+
+func TestRedirectReasons(t *testing.T) {
+	resp := HttpResponse{Redirects: []Redirect{
+		{Code: 302, From: "http://docs.mongodb.com/manual", To: "https://docs.mongodb.com/manual"},
+		{Code: 301, From: "https://docs.mongodb.com/manual", To: "https://www.mongodb.com/docs/manual/"},
+	}}
+
+	assert.Equal(t, []string{"http to https redirect", "permanent redirect", "cross-domain redirect"}, resp.RedirectReasons())
+	assert.Equal(t, "https://www.mongodb.com/docs/manual/", resp.FinalURL())
+	assert.Equal(t, "http://docs.mongodb.com/manual -[302]-> https://docs.mongodb.com/manual -[301]-> https://www.mongodb.com/docs/manual/", resp.Chain())
+}
+
+func TestPermanentTarget(t *testing.T) {
+	resp := HttpResponse{Redirects: []Redirect{
+		{Code: 301, From: "https://docs.mongodb.com/manual", To: "https://www.mongodb.com/docs/manual"},
+		{Code: 308, From: "https://www.mongodb.com/docs/manual", To: "https://www.mongodb.com/docs/manual/"},
+		{Code: 302, From: "https://www.mongodb.com/docs/manual/", To: "https://www.mongodb.com/docs/manual/?lang=en"},
+	}}
+	assert.Equal(t, "https://www.mongodb.com/docs/manual/", resp.PermanentTarget())
+
+	resp.Redirects[0].Code = 307
+	assert.Equal(t, "", resp.PermanentTarget())
+}
+
+func TestSameContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("the same page"))
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusFound)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("another page"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	checker := NewHTTPChecker(nil)
+	assert.True(t, sameContent(context.Background(), checker, srv.URL+"/a", srv.URL+"/b"))
+	assert.False(t, sameContent(context.Background(), checker, srv.URL+"/a", srv.URL+"/c"))
+	assert.False(t, sameContent(context.Background(), checker, srv.URL+"/a", srv.URL+"/missing"))
+	assert.False(t, UpgradesToHTTPS(context.Background(), checker, srv.URL+"/missing"))
+}
+
+func TestHTTPCheckerHostSettings(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bots", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "Mozilla/5.0" {
+			w.WriteHeader(999)
+			return
+		}
+		w.WriteHeader(200)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token s3cret" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+	})
+	mux.HandleFunc("/head-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			w.WriteHeader(405)
+			return
+		}
+		w.WriteHeader(200)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Setenv("CHECKER_TEST_TOKEN", "s3cret")
+	cfg, err := sources.NewCheckerConfig([]byte(`{"hosts": [
+		{"pattern": "/bots$", "accept": [999]},
+		{"pattern": "/private$", "headers": {"Authorization": "token ${CHECKER_TEST_TOKEN}"}},
+		{"pattern": "/head-only$", "method": "HEAD", "timeout": "2s"}
+	]}`))
+	assert.NoError(t, err)
+
+	resp, ok := isReachable(srv.URL + "/bots")
+	assert.False(t, ok)
+	assert.Equal(t, 999, resp.Code)
+
+	resp, ok = NewHTTPChecker(cfg).Check(context.Background(), srv.URL+"/bots")
+	assert.True(t, ok)
+	assert.True(t, resp.Accepted, "an accepted non-200 should be recorded")
+	assert.Equal(t, 999, resp.Code)
+
+	resp, ok = NewHTTPChecker(cfg).Check(context.Background(), srv.URL+"/private")
+	assert.True(t, ok)
+	assert.False(t, resp.Accepted)
+
+	resp, ok = NewHTTPChecker(cfg).Check(context.Background(), srv.URL+"/head-only")
+	assert.True(t, ok)
+	assert.Equal(t, 200, resp.Code)
+}
+
+func TestHTTPCheckerUnsetHeaderVariable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token s3cret" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer srv.Close()
+
+	cfg, err := sources.NewCheckerConfig([]byte(`{"hosts": [
+		{"pattern": "/private$", "headers": {"Authorization": "token ${CHECKER_TEST_UNSET_TOKEN}"}}
+	]}`))
+	assert.NoError(t, err)
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	checker := NewHTTPChecker(cfg)
+	for i := 0; i < 2; i++ {
+		resp, ok := checker.Check(context.Background(), srv.URL+"/private")
+		assert.False(t, ok)
+		assert.Equal(t, 401, resp.Code)
+	}
+	if assert.Len(t, hook.AllEntries(), 1, "an unset variable should be warned about once") {
+		assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+		assert.Equal(t, "The Authorization header for /private$ uses $CHECKER_TEST_UNSET_TOKEN, which isn't set, "+
+			"so it is sent without it", hook.LastEntry().Message)
+	}
+}
+
+func TestHTTPCheckerHostTimeoutAboveDefault(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(200)
+	}))
+	defer slow.Close()
+
+	defer func(timeout time.Duration) { defaultRequestTimeout = timeout }(defaultRequestTimeout)
+	defaultRequestTimeout = 50 * time.Millisecond
+
+	resp, ok := NewHTTPChecker(nil).Check(context.Background(), slow.URL)
+	assert.False(t, ok)
+	assert.Equal(t, KindTimeout, resp.Kind)
+
+	cfg, err := sources.NewCheckerConfig([]byte(`{"hosts": [{"pattern": "^` + slow.URL + `", "timeout": "2s"}]}`))
+	assert.NoError(t, err)
+	resp, ok = NewHTTPChecker(cfg).Check(context.Background(), slow.URL)
+	assert.True(t, ok, "a host's timeout applies even when it is longer than the default")
+	assert.Equal(t, 200, resp.Code)
+}